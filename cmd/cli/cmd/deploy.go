@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -35,27 +36,44 @@ var Local bool
 var Runner string
 var PullRequest string
 var StepWhitelist []string
+var Targets []string
+var Replaces []string
+var ForceUnlock bool
+var Preflight bool
+var TerraformJSONUI bool
+var FailOnDestroy bool
 
 func init() {
+	addContainerFlags(deployCmd)
 	deployCmd.Flags().StringVarP(&Version, "version", "v", "", "Version of the iac code")
-	deployCmd.Flags().StringVarP(&Environment, "environment", "e", "", "Targeted environment")
-	deployCmd.Flags().StringVarP(&Account, "account", "a", "", "Targeted Cloud Account (ie. azure subscription, gcp project)")
-	deployCmd.Flags().StringArrayVarP(&PrimaryRegions, "primary-regions", "p", []string{}, "Primary regions")
-	deployCmd.Flags().StringArrayVarP(&RegionalRegions, "regional-regions", "r", []string{}, "Regional regions")
 	deployCmd.Flags().BoolVar(&DryRun, "dry-run", false, "Dry Run")
 	deployCmd.Flags().BoolVar(&SelfDestroy, "self-destroy", false, "Teardown after running deploy")
-	deployCmd.Flags().StringVar(&LogLevel, "log-level", "", "Log level")
 	deployCmd.Flags().BoolVar(&Interactive, "interactive", false, "Run Docker container in interactive mode")
-	deployCmd.Flags().StringVarP(&Container, "container", "c", "", "The runiac core container to execute")
-	deployCmd.Flags().StringVarP(&DeploymentRing, "deployment-ring", "d", "", "The deployment ring to configure")
-	deployCmd.Flags().BoolVar(&Local, "local", false, "Pre-configure settings to create an isolated configuration specific to the executing machine")
-	deployCmd.Flags().StringVarP(&Runner, "runner", "", "terraform", "The deployment tool to use for deploying infrastructure")
 	deployCmd.Flags().StringSliceVarP(&StepWhitelist, "steps", "s", []string{}, "Only run the specified steps. To specify steps inside a track: -s {trackName}/{stepName}.  To run multiple steps, separate with a comma.  If empty, it will run all steps. To run no steps, specify a non-existent step.")
-	deployCmd.Flags().StringVar(&PullRequest, "pull-request", "", "Pre-configure settings to create an isolated configuration specific to a pull request, provide pull request identifier")
+	deployCmd.Flags().StringArrayVar(&Targets, "target", []string{}, "Only deploy the specified resource, resulting in a partial apply. Scoped to a step and optionally a region: {trackName}/{stepName}[@{region}]:{address}. Can be repeated.")
+	deployCmd.Flags().StringArrayVar(&Replaces, "replace", []string{}, "Force replacement of the specified resource. Scoped to a step and optionally a region: {trackName}/{stepName}[@{region}]:{address}. Can be repeated.")
+	deployCmd.Flags().BoolVar(&ForceUnlock, "force-unlock", false, "Force unlock state locks held by a previous run of the same step and region, e.g. after a run was killed")
+	deployCmd.Flags().BoolVar(&TerraformJSONUI, "terraform-json-ui", true, "Stream terraform's machine readable UI from plan and apply as structured, per-resource log events when the terraform version supports it (0.15.3 or later)")
+	deployCmd.Flags().BoolVar(&FailOnDestroy, "fail-on-destroy", false, "Fail dry runs of ARM steps whose what-if deletes resources")
+	deployCmd.Flags().BoolVar(&Preflight, "preflight", false, "Check the format and validity of every step before any step is deployed, failing fast when a check fails")
 
 	rootCmd.AddCommand(deployCmd)
 }
 
+// addContainerFlags adds the flags configuring the project container shared by commands executing it
+func addContainerFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&Environment, "environment", "e", "", "Targeted environment")
+	cmd.Flags().StringVarP(&Account, "account", "a", "", "Targeted Cloud Account (ie. azure subscription, gcp project)")
+	cmd.Flags().StringArrayVarP(&PrimaryRegions, "primary-regions", "p", []string{}, "Primary regions")
+	cmd.Flags().StringArrayVarP(&RegionalRegions, "regional-regions", "r", []string{}, "Regional regions")
+	cmd.Flags().StringVar(&LogLevel, "log-level", "", "Log level")
+	cmd.Flags().StringVarP(&Container, "container", "c", "", "The runiac core container to execute")
+	cmd.Flags().StringVarP(&DeploymentRing, "deployment-ring", "d", "", "The deployment ring to configure")
+	cmd.Flags().BoolVar(&Local, "local", false, "Pre-configure settings to create an isolated configuration specific to the executing machine")
+	cmd.Flags().StringVarP(&Runner, "runner", "", "terraform", "The deployment tool to use for deploying infrastructure")
+	cmd.Flags().StringVar(&PullRequest, "pull-request", "", "Pre-configure settings to create an isolated configuration specific to a pull request, provide pull request identifier")
+}
+
 var deployCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "Deploy configurations",
@@ -69,115 +87,161 @@ var deployCmd = &cobra.Command{
 			return
 		}
 
-		buildKit := "DOCKER_BUILDKIT=1"
-		containerTag := "sample"
+		containerTag := buildProjectContainer()
+
+		cmd2 := projectContainerCommand()
 
-		// check viper configuration if not set
-		if Container == "" && viper.GetString("container") != "" {
-			Container = viper.GetString("container")
+		cmd2.Args = appendEIfSet(cmd2.Args, "VERSION", Version)
+		cmd2.Args = appendEIfSet(cmd2.Args, "DRY_RUN", fmt.Sprintf("%v", DryRun))
+		cmd2.Args = appendEIfSet(cmd2.Args, "SELF_DESTROY", fmt.Sprintf("%v", SelfDestroy))
+		cmd2.Args = appendEIfSet(cmd2.Args, "STEP_WHITELIST", strings.Join(StepWhitelist, ","))
+		cmd2.Args = appendEIfSet(cmd2.Args, "TARGETS", encodeStringArray(Targets))
+		cmd2.Args = appendEIfSet(cmd2.Args, "REPLACES", encodeStringArray(Replaces))
+		cmd2.Args = appendEIfSet(cmd2.Args, "FORCE_UNLOCK", fmt.Sprintf("%v", ForceUnlock))
+		cmd2.Args = appendEIfSet(cmd2.Args, "PREFLIGHT", fmt.Sprintf("%v", Preflight))
+		cmd2.Args = appendEIfSet(cmd2.Args, "TERRAFORM_JSON_UI", fmt.Sprintf("%v", TerraformJSONUI))
+		cmd2.Args = appendEIfSet(cmd2.Args, "FAIL_ON_DESTROY", fmt.Sprintf("%v", FailOnDestroy))
+
+		if Interactive {
+			cmd2.Args = append(cmd2.Args, "-it")
 		}
 
-		cmdd := exec.Command("docker", "build", "-t", containerTag, "-f", ".runiac/Dockerfile", "--build-arg", fmt.Sprintf("RUNIAC_CONTAINER=%s", Container), ".")
+		cmd2.Args = append(cmd2.Args, containerTag)
+
+		logrus.Info(strings.Join(cmd2.Args, " "))
 
 		var stdoutBuf, stderrBuf bytes.Buffer
 
-		cmdd.Env = append(os.Environ(), buildKit)
-		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-		s.Suffix = " Building project container..."
-		s.Start()
-		b, err := cmdd.CombinedOutput()
-		if err != nil {
-			s.Stop()
-			logrus.Error(string(b))
-			logrus.WithError(err).Fatalf("Building project container failed with %s\n", err)
+		cmd2.Stdout = io.MultiWriter(os.Stdout, &stdoutBuf)
+		cmd2.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
+		cmd2.Stdin = os.Stdin
+
+		err2 := cmd2.Run()
+		if err2 != nil {
+			log.Fatalf("Running iac failed with %s\n", err2)
 		}
+	},
+}
+
+// buildProjectContainer builds the project container from the Dockerfile generated by init and returns its tag
+func buildProjectContainer() string {
+	buildKit := "DOCKER_BUILDKIT=1"
+	containerTag := "sample"
+
+	// check viper configuration if not set
+	if Container == "" && viper.GetString("container") != "" {
+		Container = viper.GetString("container")
+	}
+
+	cmdd := exec.Command("docker", "build", "-t", containerTag, "-f", ".runiac/Dockerfile", "--build-arg", fmt.Sprintf("RUNIAC_CONTAINER=%s", Container), ".")
+
+	cmdd.Env = append(os.Environ(), buildKit)
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+	s.Suffix = " Building project container..."
+	s.Start()
+	b, err := cmdd.CombinedOutput()
+	if err != nil {
 		s.Stop()
+		logrus.Error(string(b))
+		logrus.WithError(err).Fatalf("Building project container failed with %s\n", err)
+	}
+	s.Stop()
 
-		logrus.Info("Completed build, lets run!")
+	logrus.Info("Completed build, lets run!")
 
-		cmd2 := exec.Command("docker", "run", "--rm")
+	return containerTag
+}
 
-		cmd2.Env = append(os.Environ(), buildKit)
+// projectContainerCommand returns the docker run command executing the project container, configured by the container
+// flags and with the local volumes mapped. The container tag is appended by the caller after its own arguments.
+func projectContainerCommand() *exec.Cmd {
+	cmd2 := exec.Command("docker", "run", "--rm")
 
-		// pre-configure for local development experience
-		if Local {
-			namespace, err := getMachineName()
+	cmd2.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
 
-			if err != nil {
-				logrus.WithError(err).Fatal(err)
-			}
+	// pre-configure for local development experience
+	if Local {
+		namespace, err := getMachineName()
 
-			Namespace = namespace
-			DeploymentRing = "local"
-		} else if PullRequest != "" {
-			Namespace = PullRequest
-			DeploymentRing = "pr"
+		if err != nil {
+			logrus.WithError(err).Fatal(err)
 		}
 
-		cmd2.Args = appendEIfSet(cmd2.Args, "DEPLOYMENT_RING", DeploymentRing)
-		cmd2.Args = appendEIfSet(cmd2.Args, "RUNNER", Runner)
-		cmd2.Args = appendEIfSet(cmd2.Args, "NAMESPACE", Namespace)
-		cmd2.Args = appendEIfSet(cmd2.Args, "VERSION", Version)
-		cmd2.Args = appendEIfSet(cmd2.Args, "ENVIRONMENT", Environment)
-		cmd2.Args = appendEIfSet(cmd2.Args, "DRY_RUN", fmt.Sprintf("%v", DryRun))
-		cmd2.Args = appendEIfSet(cmd2.Args, "SELF_DESTROY", fmt.Sprintf("%v", SelfDestroy))
-		cmd2.Args = appendEIfSet(cmd2.Args, "STEP_WHITELIST", strings.Join(StepWhitelist, ","))
+		Namespace = namespace
+		DeploymentRing = "local"
+	} else if PullRequest != "" {
+		Namespace = PullRequest
+		DeploymentRing = "pr"
+	}
 
-		if len(PrimaryRegions) > 0 {
-			cmd2.Args = appendEIfSet(cmd2.Args, "PRIMARY_REGION", PrimaryRegions[0])
-		}
+	cmd2.Args = appendEIfSet(cmd2.Args, "DEPLOYMENT_RING", DeploymentRing)
+	cmd2.Args = appendEIfSet(cmd2.Args, "RUNNER", Runner)
+	cmd2.Args = appendEIfSet(cmd2.Args, "NAMESPACE", Namespace)
+	cmd2.Args = appendEIfSet(cmd2.Args, "ENVIRONMENT", Environment)
 
-		if len(PrimaryRegions) > 0 {
-			cmd2.Args = appendEIfSet(cmd2.Args, "REGIONAL_REGIONS", strings.Join(append(RegionalRegions, PrimaryRegions[0]), ","))
-		}
-		cmd2.Args = appendEIfSet(cmd2.Args, "ACCOUNT_ID", Account)
-		cmd2.Args = appendEIfSet(cmd2.Args, "LOG_LEVEL", LogLevel)
+	if len(PrimaryRegions) > 0 {
+		cmd2.Args = appendEIfSet(cmd2.Args, "PRIMARY_REGION", PrimaryRegions[0])
+	}
 
-		if Interactive {
-			cmd2.Args = append(cmd2.Args, "-it")
-		}
+	if len(PrimaryRegions) > 0 {
+		cmd2.Args = appendEIfSet(cmd2.Args, "REGIONAL_REGIONS", strings.Join(append(RegionalRegions, PrimaryRegions[0]), ","))
+	}
+	cmd2.Args = appendEIfSet(cmd2.Args, "ACCOUNT_ID", Account)
+	cmd2.Args = appendEIfSet(cmd2.Args, "LOG_LEVEL", LogLevel)
 
-		// TODO: how to allow consumer whitelist environment variables or simply pass all in?
-		for _, env := range cmd2.Env {
-			if strings.HasPrefix(env, "TF_VAR_") {
-				cmd2.Args = append(cmd2.Args, "-e", env)
-			}
-		}
+	// TODO: how to allow consumer whitelist environment variables or simply pass all in?
+	cmd2.Args = appendEnvNames(cmd2.Args, cmd2.Env, "TF_VAR_", "ARM_")
 
-		for _, env := range cmd2.Env {
-			if strings.HasPrefix(env, "ARM_") {
-				cmd2.Args = append(cmd2.Args, "-e", env)
-			}
-		}
+	// handle local volume maps
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		// handle local volume maps
-		dir, err := os.Getwd()
-		if err != nil {
-			log.Fatal(err)
-		}
+	// persist azure cli between container executions
+	cmd2.Args = append(cmd2.Args, "-v", fmt.Sprintf("%s/.runiac/.azure:/root/.azure", dir))
 
-		// persist azure cli between container executions
-		cmd2.Args = append(cmd2.Args, "-v", fmt.Sprintf("%s/.runiac/.azure:/root/.azure", dir))
+	// persist gcloud cli
+	cmd2.Args = append(cmd2.Args, "-v", fmt.Sprintf("%s/.runiac/.config/gcloud:/root/.config/gcloud", dir))
 
-		// persist gcloud cli
-		cmd2.Args = append(cmd2.Args, "-v", fmt.Sprintf("%s/.runiac/.config/gcloud:/root/.config/gcloud", dir))
+	// persist local terraform state between container executions
+	cmd2.Args = append(cmd2.Args, "-v", fmt.Sprintf("%s/.runiac/tfstate:/runiac/tfstate", dir))
 
-		// persist local terraform state between container executions
-		cmd2.Args = append(cmd2.Args, "-v", fmt.Sprintf("%s/.runiac/tfstate:/runiac/tfstate", dir))
+	// persist state lock holders between container executions to identify locks left behind by killed runs
+	cmd2.Args = append(cmd2.Args, "-v", fmt.Sprintf("%s/.runiac/locks:/root/.runiac/locks", dir))
 
-		cmd2.Args = append(cmd2.Args, containerTag)
+	// persist state backups taken before state modifications outside of the container
+	cmd2.Args = append(cmd2.Args, "-v", fmt.Sprintf("%s/.runiac/state-backups:/root/.runiac/state-backups", dir))
 
-		logrus.Info(strings.Join(cmd2.Args, " "))
+	return cmd2
+}
 
-		cmd2.Stdout = io.MultiWriter(os.Stdout, &stdoutBuf)
-		cmd2.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
-		cmd2.Stdin = os.Stdin
+// appendEnvNames forwards the environment variables with any of the prefixes to the container by name only, docker
+// reads their values from its own environment so secrets such as ARM_CLIENT_SECRET are not part of the logged command
+func appendEnvNames(slice []string, environ []string, prefixes ...string) []string {
+	for _, env := range environ {
+		name := strings.SplitN(env, "=", 2)[0]
 
-		err2 := cmd2.Run()
-		if err2 != nil {
-			log.Fatalf("Running iac failed with %s\n", err2)
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				slice = append(slice, "-e", name)
+				break
+			}
 		}
-	},
+	}
+
+	return slice
+}
+
+// encodeStringArray encodes the values as a JSON array, so values containing commas, e.g. resource addresses with string
+// keys, are passed to the container intact. Returns an empty string without values.
+func encodeStringArray(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
 }
 
 func appendEIfSet(slice []string, arg string, val string) []string {
@@ -1,6 +1,9 @@
 package cmd
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestSanitizeMachinename(t *testing.T) {
 	tests := map[string]string{
@@ -20,3 +23,27 @@ func TestSanitizeMachinename(t *testing.T) {
 		}
 	}
 }
+
+func TestAppendEnvNames_ShouldNotIncludeValues(t *testing.T) {
+	args := appendEnvNames([]string{}, []string{"ARM_CLIENT_SECRET=super-secret", "TF_VAR_name=value", "PATH=/usr/bin"}, "TF_VAR_", "ARM_")
+
+	expected := []string{"-e", "ARM_CLIENT_SECRET", "-e", "TF_VAR_name"}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("appendEnvNames() = %v; want %v", args, expected)
+	}
+}
+
+func TestEncodeStringArray_ShouldKeepCommasWithinValues(t *testing.T) {
+	tests := map[string][]string{
+		"": {},
+		`["core/dns:aws_route53_record.x[\"a,b\"]"]`: {`core/dns:aws_route53_record.x["a,b"]`},
+		`["core/dns:a.b","default/network:c.d"]`:     {"core/dns:a.b", "default/network:c.d"},
+	}
+
+	for expected, values := range tests {
+		result := encodeStringArray(values)
+		if result != expected {
+			t.Errorf("encodeStringArray(%v) = %q; want %q", values, result, expected)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var PrintEnv bool
+
+func init() {
+	addContainerFlags(execCmd)
+	execCmd.Flags().BoolVar(&PrintEnv, "print-env", false, "Print the step's environment as a script for a shell in the project container rather than running a command")
+
+	rootCmd.AddCommand(execCmd)
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec {trackName}/{stepName}[@{region}] -- [runner arguments]",
+	Short: "Run a runner command within a step",
+	Long: `This will prepare a single step execution exactly as deploy would, without deploying it, and then run the
+runner (terraform or az) with the given arguments in it, e.g. "runiac exec default/network -- state list".
+
+Without a region the step's primary execution is prepared, otherwise its regional execution in that region.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		step, runnerArgs, err := parseExecArgs(args, cmd.ArgsLenAtDash())
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if !PrintEnv && len(runnerArgs) == 0 {
+			logrus.Fatal("Specify the runner arguments to run within the step after --, e.g. runiac exec default/network -- state list")
+		}
+
+		checkDockerExists()
+
+		ok := checkInitialized()
+		if !ok {
+			fmt.Printf("You need to run 'runiac init' before you can use the CLI in this directory\n")
+			return
+		}
+
+		containerTag := buildProjectContainer()
+
+		cmd2 := projectContainerCommand()
+
+		encodedArgs, _ := json.Marshal(runnerArgs)
+
+		cmd2.Args = appendEIfSet(cmd2.Args, "EXEC_STEP", step)
+		cmd2.Args = appendEIfSet(cmd2.Args, "EXEC_ARGS", string(encodedArgs))
+		cmd2.Args = appendEIfSet(cmd2.Args, "EXEC_PRINT_ENV", fmt.Sprintf("%v", PrintEnv))
+
+		// keep stdin open for interactive commands such as console, a tty would merge the printed environment with logs
+		cmd2.Args = append(cmd2.Args, "-i")
+
+		if !PrintEnv && isTerminal(os.Stdin) {
+			cmd2.Args = append(cmd2.Args, "-t")
+		}
+
+		cmd2.Args = append(cmd2.Args, containerTag)
+
+		logrus.Info(strings.Join(cmd2.Args, " "))
+
+		cmd2.Stdout = os.Stdout
+		cmd2.Stderr = os.Stderr
+		cmd2.Stdin = os.Stdin
+
+		err = cmd2.Run()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		} else if err != nil {
+			log.Fatalf("Running iac failed with %s\n", err)
+		}
+	},
+}
+
+// parseExecArgs splits the exec command's arguments into the step and the runner arguments following --
+func parseExecArgs(args []string, argsLenAtDash int) (string, []string, error) {
+	if argsLenAtDash == -1 {
+		if len(args) > 1 {
+			return "", nil, fmt.Errorf("separate the runner arguments from the step with --, e.g. runiac exec %s -- %s", args[0], strings.Join(args[1:], " "))
+		}
+		return args[0], []string{}, nil
+	}
+
+	if argsLenAtDash != 1 {
+		return "", nil, fmt.Errorf("specify exactly one step before --")
+	}
+
+	return args[0], args[1:], nil
+}
+
+// isTerminal returns whether the file is a terminal, e.g. stdin is not redirected
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
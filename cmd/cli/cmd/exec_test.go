@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExecArgs(t *testing.T) {
+	tests := map[string]struct {
+		args          []string
+		argsLenAtDash int
+		step          string
+		runnerArgs    []string
+		err           bool
+	}{
+		"runner arguments": {args: []string{"default/network", "state", "list"}, argsLenAtDash: 1, step: "default/network", runnerArgs: []string{"state", "list"}},
+		"print env":        {args: []string{"default/network@us-east-1"}, argsLenAtDash: -1, step: "default/network@us-east-1", runnerArgs: []string{}},
+		"missing dash":     {args: []string{"default/network", "state", "list"}, argsLenAtDash: -1, err: true},
+		"multiple steps":   {args: []string{"default/network", "default/dns", "state", "list"}, argsLenAtDash: 2, err: true},
+	}
+
+	for name, test := range tests {
+		step, runnerArgs, err := parseExecArgs(test.args, test.argsLenAtDash)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: parseExecArgs(%v, %d) should fail", name, test.args, test.argsLenAtDash)
+			}
+			continue
+		}
+
+		if err != nil || step != test.step || !reflect.DeepEqual(runnerArgs, test.runnerArgs) {
+			t.Errorf("%s: parseExecArgs(%v, %d) = %q, %v, %v; want %q, %v", name, test.args, test.argsLenAtDash, step, runnerArgs, err, test.step, test.runnerArgs)
+		}
+	}
+}
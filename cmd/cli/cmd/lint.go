@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addContainerFlags(lintCmd)
+
+	rootCmd.AddCommand(lintCmd)
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the format and validity of every step",
+	Long: `This will run the pre-flight checks of every step and regional directory without deploying anything or
+contacting a backend: terraform fmt -check and terraform validate for terraform steps, az deployment validate for
+ARM steps. All failed checks are reported together.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkDockerExists()
+
+		ok := checkInitialized()
+		if !ok {
+			fmt.Printf("You need to run 'runiac init' before you can use the CLI in this directory\n")
+			return
+		}
+
+		containerTag := buildProjectContainer()
+
+		cmd2 := projectContainerCommand()
+
+		cmd2.Args = appendEIfSet(cmd2.Args, "LINT", "true")
+
+		cmd2.Args = append(cmd2.Args, containerTag)
+
+		logrus.Info(strings.Join(cmd2.Args, " "))
+
+		cmd2.Stdout = os.Stdout
+		cmd2.Stderr = os.Stderr
+		cmd2.Stdin = os.Stdin
+
+		err := cmd2.Run()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		} else if err != nil {
+			log.Fatalf("Running iac failed with %s\n", err)
+		}
+	},
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/optum/runiac/pkg/logging"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -40,4 +41,8 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err != nil {
 		logrus.WithError(err).Warn("Failed reading .runiac configuration")
 	}
+
+	// redact secrets of the host environment, e.g. from the logged container commands
+	logging.DefaultRedactor.RegisterFromEnv(os.Environ(), viper.GetStringSlice("secret_keys"))
+	logrus.AddHook(logging.NewRedactionHook(logging.DefaultRedactor))
 }
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{stateListCmd, stateShowCmd, stateMvCmd, stateRmCmd} {
+		addContainerFlags(cmd)
+		stateCmd.AddCommand(cmd)
+	}
+
+	rootCmd.AddCommand(stateCmd)
+}
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage the state of steps",
+	Long: `These commands resolve a step and optionally a region to the backend configuration and workspace deploy would
+use, and run the corresponding state command in it.
+
+Resources are addressed as {trackName}/{stepName}[@{region}]:{address}. Without a region the step's primary execution
+is used, otherwise its regional execution in that region. The state of every execution is backed up to
+.runiac/state-backups before it is modified.`,
+}
+
+var stateListCmd = &cobra.Command{
+	Use:   "list {trackName}/{stepName}[@{region}]",
+	Short: "List the resources in the state of a step",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runStateCommand("list", args)
+	},
+}
+
+var stateShowCmd = &cobra.Command{
+	Use:   "show {trackName}/{stepName}[@{region}]:{address}",
+	Short: "Show a resource in the state of a step",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runStateCommand("show", args)
+	},
+}
+
+var stateMvCmd = &cobra.Command{
+	Use:   "mv {trackName}/{stepName}[@{region}]:{source} {trackName}/{stepName}[@{region}]:{destination}",
+	Short: "Move a resource within the state of a step or between the states of steps and regions",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runStateCommand("mv", args)
+	},
+}
+
+var stateRmCmd = &cobra.Command{
+	Use:   "rm {trackName}/{stepName}[@{region}]:{address}...",
+	Short: "Remove resources from the state of a step without destroying them",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runStateCommand("rm", args)
+	},
+}
+
+// runStateCommand runs the state command with the arguments in the project container
+func runStateCommand(command string, args []string) {
+	checkDockerExists()
+
+	ok := checkInitialized()
+	if !ok {
+		fmt.Printf("You need to run 'runiac init' before you can use the CLI in this directory\n")
+		return
+	}
+
+	containerTag := buildProjectContainer()
+
+	cmd2 := projectContainerCommand()
+
+	encodedArgs, _ := json.Marshal(args)
+
+	cmd2.Args = appendEIfSet(cmd2.Args, "STATE_COMMAND", command)
+	cmd2.Args = appendEIfSet(cmd2.Args, "STATE_ARGS", string(encodedArgs))
+
+	cmd2.Args = append(cmd2.Args, containerTag)
+
+	logrus.Info(strings.Join(cmd2.Args, " "))
+
+	cmd2.Stdout = os.Stdout
+	cmd2.Stderr = os.Stderr
+	cmd2.Stdin = os.Stdin
+
+	err := cmd2.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	} else if err != nil {
+		log.Fatalf("Running iac failed with %s\n", err)
+	}
+}
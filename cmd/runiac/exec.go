@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/gruntwork-io/gruntwork-cli/errors"
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/shell"
+	"github.com/optum/runiac/pkg/steps"
+)
+
+// execStep prepares the configured step execution and runs the configured runner arguments within it, or prints its
+// environment, returning the exit code of the process
+func execStep(cfg config.Config) int {
+	// the printed working directory is used after the process exits, so it is kept
+	if !cfg.ExecPrintEnv {
+		defer steps.CleanupScratch(fs, log, cfg)
+	}
+
+	address, err := config.ParseStepAddress(cfg.ExecStep)
+	if err != nil {
+		log.WithError(err).Error("Invalid step to execute")
+		return 1
+	}
+
+	args, err := config.ParseExecArgs(cfg.ExecArgs)
+	if err != nil {
+		log.WithError(err).Error("Invalid arguments to execute")
+		return 1
+	}
+
+	exec, command, err := tracker.PrepareStepCommand(cfg, address)
+	if err != nil {
+		log.WithError(err).Errorf("Unable to prepare step %s", cfg.ExecStep)
+		return 1
+	}
+
+	if cfg.ExecPrintEnv {
+		printEnv(os.Stdout, command)
+		return 0
+	}
+
+	err = shell.RunShellCommand(shell.Command{
+		Command:    command.Binary,
+		Args:       args,
+		WorkingDir: command.Dir,
+		Env:        command.Env,
+		Logger:     exec.Logger,
+	})
+
+	if err != nil {
+		// propagate the runner's exit code, e.g. for plan -detailed-exitcode
+		code, codeErr := shell.GetExitCodeForRunCommandError(errors.Unwrap(err))
+		if codeErr != nil || code == 0 {
+			exec.Logger.WithError(err).Errorf("Error running %s", command.Binary)
+			return 1
+		}
+		return code
+	}
+
+	return 0
+}
+
+// printEnv writes the step's environment as a script a shell can evaluate. The working directory and the paths in the
+// environment, e.g. TF_DATA_DIR and TF_PLUGIN_CACHE_DIR, are paths within the container the execution was prepared in
+func printEnv(w io.Writer, command config.StepCommand) {
+	keys := make([]string, 0, len(command.Env))
+	for k := range command.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "# runner: %s\n", command.Binary)
+	fmt.Fprintf(w, "# working directory: %s\n", command.Dir)
+	fmt.Fprintln(w, "# paths are within the runiac container, evaluate this in a shell of the project's container")
+
+	for _, k := range keys {
+		fmt.Fprintf(w, "export %s=%s\n", k, shell.Quote(command.Env[k]))
+	}
+}
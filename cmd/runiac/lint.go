@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/tracks"
+)
+
+// lint runs the pre-flight checks of every step without deploying anything, returning the exit code of the process
+func lint(cfg config.Config) int {
+	issues := tracker.PreflightCheck(cfg)
+
+	if len(issues) > 0 {
+		log.Errorf("Pre-flight checks failed with %d issue(s):\n%s", len(issues), tracks.PreflightReport(issues))
+		return 1
+	}
+
+	log.Info("Pre-flight checks passed")
+
+	return 0
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,6 +27,18 @@ var log *logrus.Entry
 func main() {
 	initFunc()
 
+	if deployment.Config.ExecStep != "" {
+		os.Exit(execStep(deployment.Config))
+	}
+
+	if deployment.Config.StateCommand != "" {
+		os.Exit(runStateCommand(deployment.Config))
+	}
+
+	if deployment.Config.Lint {
+		os.Exit(lint(deployment.Config))
+	}
+
 	log.Debugf("Beginning Account Deployment: %s", deployment.Config.AccountID)
 
 	log.Debug("Executing tracks...")
@@ -39,6 +52,13 @@ func main() {
 	skippedSteps := []string{}
 	skippedTracks := []string{}
 	failedDestroySteps := []string{}
+	runnerVersions := []string{}
+	partialApplies := []string{}
+	replacements := []string{}
+	stateLocks := []string{}
+	erroredResources := []string{}
+	plannedChanges := []string{}
+	overrides := []string{}
 	stepCount := 0
 	executedStepCount := 0
 	failedTestCount := 0
@@ -54,6 +74,36 @@ func main() {
 			failedTestCount += tExecution.Output.FailedTestCount
 
 			for _, s := range tExecution.Output.Steps {
+				if s.Output.RunnerVersion != "" {
+					runnerVersions = append(runnerVersions, fmt.Sprintf("%v/%v/%v/%v=%v", t.Name, s.Name, tExecution.RegionDeployType, tExecution.Region, s.Output.RunnerVersion))
+				}
+
+				if len(s.Output.Targets) > 0 {
+					partialApplies = append(partialApplies, fmt.Sprintf("%v/%v/%v/%v=[%v]", t.Name, s.Name, tExecution.RegionDeployType, tExecution.Region, strings.Join(s.Output.Targets, " ")))
+				}
+
+				if s.Output.StateLock != nil {
+					stateLocks = append(stateLocks, fmt.Sprintf("%v/%v/%v/%v=%v (%v, force unlocked: %v)", t.Name, s.Name, tExecution.RegionDeployType, tExecution.Region, s.Output.StateLock.ID, s.Output.StateLock.Who, s.Output.StateLock.ForceUnlocked))
+				}
+
+				for _, r := range s.Output.ResourceResults {
+					if r.Errored {
+						erroredResources = append(erroredResources, fmt.Sprintf("%v/%v/%v/%v=%v (%v)", t.Name, s.Name, tExecution.RegionDeployType, tExecution.Region, r.Address, r.Action))
+					}
+				}
+
+				if s.Output.Plan != nil && s.Output.Plan.HasChanges() {
+					plannedChanges = append(plannedChanges, fmt.Sprintf("%v/%v/%v/%v=+%v ~%v -%v", t.Name, s.Name, tExecution.RegionDeployType, tExecution.Region, s.Output.Plan.Add, s.Output.Plan.Change, s.Output.Plan.Destroy))
+				}
+
+				if len(s.Output.Overrides) > 0 {
+					overrides = append(overrides, fmt.Sprintf("%v/%v/%v/%v=[%v]", t.Name, s.Name, tExecution.RegionDeployType, tExecution.Region, strings.Join(s.Output.Overrides, " ")))
+				}
+
+				if len(s.Output.Replaces) > 0 {
+					replacements = append(replacements, fmt.Sprintf("%v/%v/%v/%v=[%v]", t.Name, s.Name, tExecution.RegionDeployType, tExecution.Region, strings.Join(s.Output.Replaces, " ")))
+				}
+
 				switch s.Output.Status {
 				case config.Fail:
 					failedSteps = append(failedSteps, fmt.Sprintf("%v/%v/%v/%v", t.Name, s.Name, tExecution.RegionDeployType, tExecution.Region))
@@ -88,6 +138,11 @@ func main() {
 		result = "fail"
 	}
 
+	if len(output.PreflightIssues) > 0 {
+		resultMessage += fmt.Sprintf("  Pre-flight checks failed: %v issue(s).", len(output.PreflightIssues))
+		result = "fail"
+	}
+
 	if len(failedDestroySteps) > 0 {
 		resultMessage += fmt.Sprintf("  Failed to destroy: %v.", strings.Join(failedDestroySteps, ", "))
 		result = "fail"
@@ -101,6 +156,44 @@ func main() {
 		"result":        result,
 	})
 
+	if len(runnerVersions) > 0 {
+		sort.Strings(runnerVersions)
+		slog = slog.WithField("runnerVersions", strings.Join(runnerVersions, ","))
+	}
+
+	if len(partialApplies) > 0 {
+		sort.Strings(partialApplies)
+		slog = slog.WithField("partialApply", strings.Join(partialApplies, ","))
+		slog.Warnf("PARTIAL APPLY: only targeted resources were deployed, the deployed infrastructure may not match the configuration: %s", strings.Join(partialApplies, ", "))
+	}
+
+	if len(stateLocks) > 0 {
+		sort.Strings(stateLocks)
+		slog = slog.WithField("stateLocks", strings.Join(stateLocks, ","))
+		resultMessage += fmt.Sprintf("  State locked: %v.", strings.Join(stateLocks, ", "))
+	}
+
+	if len(replacements) > 0 {
+		sort.Strings(replacements)
+		slog = slog.WithField("replaced", strings.Join(replacements, ","))
+		slog.Warnf("FORCED REPLACEMENT: the following resources were replaced: %s", strings.Join(replacements, ", "))
+	}
+
+	if len(overrides) > 0 {
+		sort.Strings(overrides)
+		slog = slog.WithField("overrides", strings.Join(overrides, ","))
+	}
+
+	if len(plannedChanges) > 0 {
+		sort.Strings(plannedChanges)
+		slog = slog.WithField("plannedChanges", strings.Join(plannedChanges, ","))
+	}
+
+	if len(erroredResources) > 0 {
+		sort.Strings(erroredResources)
+		slog = slog.WithField("erroredResources", strings.Join(erroredResources, ","))
+	}
+
 	if result == "success" {
 		slog.Info(resultMessage)
 	} else {
@@ -122,6 +215,11 @@ func initFunc() {
 		})
 	}
 	logger.SetReportCaller(true)
+
+	// redact secrets before any formatter runs
+	logging.DefaultRedactor.RegisterFromEnv(os.Environ(), nil)
+	logger.AddHook(logging.NewRedactionHook(logging.DefaultRedactor))
+
 	log = logrus.NewEntry(logger)
 
 	deployment = config.Deployment{}
@@ -136,6 +234,8 @@ func initFunc() {
 		log.WithError(err).Fatal(err.Error())
 	}
 
+	logging.DefaultRedactor.RegisterFromEnv(os.Environ(), deployment.Config.SecretKeys)
+
 	// Only log the warning severity or above.
 	lvl, err := logrus.ParseLevel(deployment.Config.LogLevel)
 
@@ -179,20 +279,20 @@ func initFunc() {
 		"uniqueExternalExecutionID": deployment.Config.UniqueExternalExecutionID,
 	})
 
-	// init tracker last to ensure log configuration is set correctly
-	tracker = tracks.DirectoryBasedTracker{
-		Log: log,
-		Fs:  fs,
-	}
-
 	// initialize the runner plugin
 	plugin, err := getRunnerPlugin(deployment.Config)
 	if err != nil {
 		log.WithError(err).Error("Could not determine runner plugin")
-		return
+	} else {
+		plugin.Initialize(log)
 	}
 
-	plugin.Initialize(log)
+	// init tracker last to ensure log configuration is set correctly
+	tracker = tracks.DirectoryBasedTracker{
+		Log:    log,
+		Fs:     fs,
+		Plugin: plugin,
+	}
 }
 
 func getRunnerPlugin(config config.Config) (config.RunnerPlugin, error) {
@@ -200,7 +300,12 @@ func getRunnerPlugin(config config.Config) (config.RunnerPlugin, error) {
 	case "arm":
 		return pluginsarm.ArmPlugin{}, nil
 	case "terraform":
-		return pluginsterraform.TerraformPlugin{}, nil
+		return pluginsterraform.TerraformPlugin{
+			Flavor:               config.TerraformFlavor,
+			PluginCacheDir:       config.PluginCacheDir,
+			TerraformVersionsDir: config.TerraformVersionsDir,
+			Fs:                   fs,
+		}, nil
 	default:
 		return nil, errors.New("Invalid runner")
 	}
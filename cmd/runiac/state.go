@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/steps"
+)
+
+// stateTarget is a step execution and the resource addresses within its state a state command operates on
+type stateTarget struct {
+	Step      config.StepAddress
+	Addresses []string
+}
+
+// parseStateArgs parses the arguments of a state command. list takes a step execution, {stepID}[@{region}], show
+// takes a resource address, {stepID}[@{region}]:{address}, rm takes resource addresses of a single execution and mv
+// takes the source and destination resource addresses, which may be in different executions.
+func parseStateArgs(command string, args []string) ([]stateTarget, error) {
+	if command == "list" {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("state list expects a single step, {stepID}[@{region}]")
+		}
+
+		step, err := config.ParseStepAddress(args[0])
+
+		return []stateTarget{{Step: step}}, err
+	}
+
+	targets := []stateTarget{}
+
+	for _, arg := range args {
+		addr, err := config.ParseResourceAddress(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, stateTarget{
+			Step:      config.StepAddress{StepID: addr.StepID, Region: addr.Region},
+			Addresses: []string{addr.Address},
+		})
+	}
+
+	switch command {
+	case "show":
+		if len(targets) != 1 {
+			return nil, fmt.Errorf("state show expects a single resource address, {stepID}[@{region}]:{address}")
+		}
+	case "mv":
+		if len(targets) != 2 {
+			return nil, fmt.Errorf("state mv expects a source and a destination resource address, {stepID}[@{region}]:{address}")
+		}
+	case "rm":
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("state rm expects at least one resource address, {stepID}[@{region}]:{address}")
+		}
+
+		for _, target := range targets[1:] {
+			if target.Step != targets[0].Step {
+				return nil, fmt.Errorf("state rm expects resource addresses of a single step execution")
+			}
+			targets[0].Addresses = append(targets[0].Addresses, target.Addresses...)
+		}
+
+		targets = targets[:1]
+	default:
+		return nil, fmt.Errorf("unknown state command %s, expected list, show, mv or rm", command)
+	}
+
+	return targets, nil
+}
+
+// runStateCommand prepares the step executions addressed by the configured state command and runs it, returning the
+// exit code of the process. The output of list and show is written to stdout, logs are written to stderr.
+func runStateCommand(cfg config.Config) int {
+	defer steps.CleanupScratch(fs, log, cfg)
+
+	args, err := config.ParseExecArgs(cfg.StateArgs)
+	if err != nil {
+		log.WithError(err).Error("Invalid state command arguments")
+		return 1
+	}
+
+	targets, err := parseStateArgs(cfg.StateCommand, args)
+	if err != nil {
+		log.WithError(err).Error("Invalid state command arguments")
+		return 1
+	}
+
+	executions := []config.StepExecution{}
+	var manager config.StateManager
+
+	for _, target := range targets {
+		step, exec, err := tracker.PrepareStepExecution(cfg, target.Step)
+		if err != nil {
+			log.WithError(err).Errorf("Unable to prepare step %s", target.Step.StepID)
+			return 1
+		}
+
+		var ok bool
+		manager, ok = step.Runner.(config.StateManager)
+		if !ok {
+			log.Errorf("The %s runner does not support managing state", cfg.Runner)
+			return 1
+		}
+
+		executions = append(executions, exec)
+	}
+
+	if err = runState(os.Stdout, manager, cfg.StateCommand, targets, executions); err != nil {
+		log.WithError(err).Errorf("Error running state %s", cfg.StateCommand)
+		return 1
+	}
+
+	return 0
+}
+
+// runState runs the state command on the prepared executions of its targets
+func runState(w io.Writer, manager config.StateManager, command string, targets []stateTarget, executions []config.StepExecution) error {
+	switch command {
+	case "list":
+		addresses, err := manager.ListState(executions[0])
+		if err != nil {
+			return err
+		}
+
+		for _, address := range addresses {
+			fmt.Fprintln(w, address)
+		}
+	case "show":
+		resource, err := manager.ShowState(executions[0], targets[0].Addresses[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(w, resource)
+	case "mv":
+		return manager.MoveState(executions[0], targets[0].Addresses[0], executions[1], targets[1].Addresses[0])
+	case "rm":
+		return manager.RemoveState(executions[0], targets[0].Addresses)
+	}
+
+	return nil
+}
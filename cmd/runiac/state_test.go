@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStateArgs(t *testing.T) {
+	tests := map[string]struct {
+		command  string
+		args     []string
+		expected []stateTarget
+		err      bool
+	}{
+		"list primary": {
+			command:  "list",
+			args:     []string{"default/network"},
+			expected: []stateTarget{{Step: config.StepAddress{StepID: "default/network"}}},
+		},
+		"show regional": {
+			command:  "show",
+			args:     []string{"default/network@us-east-1:aws_vpc.main"},
+			expected: []stateTarget{{Step: config.StepAddress{StepID: "default/network", Region: "us-east-1"}, Addresses: []string{"aws_vpc.main"}}},
+		},
+		"mv across steps": {
+			command: "mv",
+			args:    []string{"default/network:aws_route53_zone.main", "default/dns:aws_route53_zone.main"},
+			expected: []stateTarget{
+				{Step: config.StepAddress{StepID: "default/network"}, Addresses: []string{"aws_route53_zone.main"}},
+				{Step: config.StepAddress{StepID: "default/dns"}, Addresses: []string{"aws_route53_zone.main"}},
+			},
+		},
+		"rm multiple": {
+			command:  "rm",
+			args:     []string{"default/network:aws_vpc.main", "default/network:aws_subnet.a"},
+			expected: []stateTarget{{Step: config.StepAddress{StepID: "default/network"}, Addresses: []string{"aws_vpc.main", "aws_subnet.a"}}},
+		},
+		"list resource address": {command: "list", args: []string{"default/network:aws_vpc.main"}, err: true},
+		"show without address":  {command: "show", args: []string{"default/network"}, err: true},
+		"mv single address":     {command: "mv", args: []string{"default/network:aws_vpc.main"}, err: true},
+		"rm across steps":       {command: "rm", args: []string{"default/network:aws_vpc.main", "default/dns:aws_vpc.main"}, err: true},
+		"rm across regions":     {command: "rm", args: []string{"default/network:aws_vpc.main", "default/network@us-east-1:aws_vpc.main"}, err: true},
+		"unknown command":       {command: "push", args: []string{"default/network:aws_vpc.main"}, err: true},
+	}
+
+	for name, test := range tests {
+		targets, err := parseStateArgs(test.command, test.args)
+		if test.err {
+			require.Error(t, err, name)
+			continue
+		}
+
+		require.NoError(t, err, name)
+		require.Equal(t, test.expected, targets, name)
+	}
+}
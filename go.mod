@@ -12,6 +12,8 @@ require (
 	github.com/google/go-licenses v0.0.0-20201026145851-73411c8fa237 // indirect
 	github.com/gruntwork-io/gruntwork-cli v0.4.2
 	github.com/gruntwork-io/terratest v0.17.5
+	github.com/hashicorp/go-version v1.6.0
+	github.com/hashicorp/hcl/v2 v2.10.0
 	github.com/mitchellh/mapstructure v1.1.2
 	github.com/otiai10/copy v1.4.2
 	github.com/sirupsen/logrus v1.4.2
@@ -22,5 +24,6 @@ require (
 	github.com/spf13/viper v1.7.0
 	github.com/stretchr/testify v1.4.0
 	github.com/urfave/cli v1.22.1 // indirect
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	github.com/zclconf/go-cty v1.8.0
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ResourceAddress is a terraform resource address scoped to a step and optionally a region, parsed from
+// {stepID}[@{region}]:{address}, e.g. default/network@us-east-1:aws_vpc.main
+type ResourceAddress struct {
+	StepID  string
+	Region  string // Empty matches all regions
+	Address string
+}
+
+// ParseResourceAddress parses a step scoped resource address
+func ParseResourceAddress(s string) (ResourceAddress, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ResourceAddress{}, fmt.Errorf("invalid resource address %q, expected {stepID}[@{region}]:{address}", s)
+	}
+
+	scope := strings.SplitN(parts[0], "@", 2)
+
+	addr := ResourceAddress{
+		StepID:  scope[0],
+		Address: parts[1],
+	}
+
+	if len(scope) == 2 {
+		addr.Region = scope[1]
+	}
+
+	if addr.StepID == "" || (len(scope) == 2 && addr.Region == "") {
+		return ResourceAddress{}, fmt.Errorf("invalid resource address %q, expected {stepID}[@{region}]:{address}", s)
+	}
+
+	return addr, nil
+}
+
+// Matches returns whether the resource address applies to the step execution
+func (a ResourceAddress) Matches(stepID string, region string) bool {
+	return a.StepID == stepID && (a.Region == "" || a.Region == region)
+}
+
+// FilterResourceAddresses returns the addresses that apply to the step execution, invalid addresses are ignored
+// since they are reported when the configuration is validated
+func FilterResourceAddresses(addresses []string, stepID string, region string) []string {
+	matched := []string{}
+
+	for _, s := range addresses {
+		addr, err := ParseResourceAddress(s)
+		if err != nil {
+			continue
+		}
+
+		if addr.Matches(stepID, region) {
+			matched = append(matched, addr.Address)
+		}
+	}
+
+	return matched
+}
+
+// StepAddress identifies a single execution of a step, parsed from {stepID}[@{region}]. Without a region it is the
+// step's primary execution, otherwise the step's regional execution in that region.
+type StepAddress struct {
+	StepID string
+	Region string
+}
+
+// ParseStepAddress parses a step execution address, e.g. default/network@us-east-1
+func ParseStepAddress(s string) (StepAddress, error) {
+	scope := strings.SplitN(strings.TrimSpace(s), "@", 2)
+
+	addr := StepAddress{
+		StepID: scope[0],
+	}
+
+	if len(scope) == 2 {
+		addr.Region = scope[1]
+	}
+
+	if addr.StepID == "" || strings.Contains(addr.StepID, ":") || (len(scope) == 2 && addr.Region == "") {
+		return StepAddress{}, fmt.Errorf("invalid step address %q, expected {stepID}[@{region}]", s)
+	}
+
+	return addr, nil
+}
+
+// ParseExecArgs parses the JSON array of runner arguments to execute within a step, e.g. ["state","list"]
+func ParseExecArgs(s string) ([]string, error) {
+	args := []string{}
+
+	if strings.TrimSpace(s) == "" {
+		return args, nil
+	}
+
+	if err := json.Unmarshal([]byte(s), &args); err != nil {
+		return nil, fmt.Errorf("invalid exec args %q, expected a JSON array of strings: %w", s, err)
+	}
+
+	return args, nil
+}
@@ -1,11 +1,19 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"github.com/mitchellh/mapstructure"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -24,9 +32,9 @@ type Config struct {
 	RegionalRegions []string `mapstructure:"regional_regions"` // runiac will apply regional step deployments across these regions
 	PrimaryRegion   string   `mapstructure:"primary_region" required:"true"`
 	DryRun          bool     `mapstructure:"dry_run"` // DryRun will only execute up to Terraform plan, describing what will happen if deployed
-	Runner          string   `mapstructure:runner`    // Delivery framework to invoke for executing steps
+	Runner          string   `mapstructure:"runner"`  // Delivery framework to invoke for executing steps
 
-	UniqueExternalExecutionID string
+	UniqueExternalExecutionID string `mapstructure:"unique_external_execution_id"` // Identifies the run, generated when not set
 	DeploymentRing            string `mapstructure:"deployment_ring"`
 	SelfDestroy               bool   `mapstructure:"self_destroy"` // Destroy will automatically execute Terraform Destroy after running deployments & tests
 	RegionGroup               string
@@ -38,6 +46,28 @@ type Config struct {
 	LogLevel                  string          `mapstructure:"log_level"`
 	CoreAccounts              CoreAccountsMap `mapstructure:"core_accounts"`
 	RegionGroups              RegionGroupsMap `mapstructure:"region_grouprs"`
+	SecretKeys                []string        `mapstructure:"secret_keys"`                 // Additional environment variable names whose values are redacted from all logs
+	TerraformVersionsDir      string          `mapstructure:"terraform_versions_dir"`      // Directory of installed terraform versions, laid out as {dir}/{version}/terraform
+	TerraformFlavor           string          `mapstructure:"terraform_flavor"`            // The terraform compatible CLI to execute steps with: terraform, tofu or terragrunt
+	Targets                   []string        `mapstructure:"targets"`                     // Resource addresses to target, scoped as {stepID}[@{region}]:{address}. Results in a partial apply
+	Replaces                  []string        `mapstructure:"replaces"`                    // Resource addresses to force replacement of, scoped as {stepID}[@{region}]:{address}
+	PluginCacheDir            string          `mapstructure:"plugin_cache_dir"`            // Provider plugin cache shared by all steps, defaults to TF_PLUGIN_CACHE_DIR or $HOME/.terraform.d/plugin-cache
+	PluginCacheMayBreakLock   bool            `mapstructure:"plugin_cache_may_break_lock"` // Use the plugin cache for steps without a dependency lock file, skipping its checksum verification
+	RetryableErrors           []string        `mapstructure:"retryable_errors"`            // Additional regular expressions of runner errors to retry, extending the runner's catalog
+	ForceUnlock               bool            `mapstructure:"force_unlock"`                // Force unlock state locks held by a previous run of the same step and region
+	LockLedgerDir             string          `mapstructure:"lock_ledger_dir"`             // Directory recording the state lock holders of runs, defaults to $HOME/.runiac/locks
+	ExecStep                  string          `mapstructure:"exec_step"`                   // Prepares this step execution, {stepID}[@{region}], and runs ExecArgs in it rather than deploying
+	ExecArgs                  string          `mapstructure:"exec_args"`                   // JSON array of the runner arguments to run in the ExecStep execution
+	ExecPrintEnv              bool            `mapstructure:"exec_print_env"`              // Print the environment of the ExecStep execution for a container shell rather than running ExecArgs
+	StateCommand              string          `mapstructure:"state_command"`               // Runs this state command, list, show, mv or rm, with StateArgs rather than deploying
+	StateArgs                 string          `mapstructure:"state_args"`                  // JSON array of the StateCommand arguments, state addresses scoped as {stepID}[@{region}]:{address}
+	StateBackupDir            string          `mapstructure:"state_backup_dir"`            // Directory of the state backups taken before every state mutation, defaults to $HOME/.runiac/state-backups
+	Preflight                 bool            `mapstructure:"preflight"`                   // Check the format and validity of every step before any step is deployed
+	Lint                      bool            `mapstructure:"lint"`                        // Only run the pre-flight checks rather than deploying
+	TerraformJSONUI           bool            `mapstructure:"terraform_json_ui"`           // Stream terraform's machine readable UI from plan and apply as structured events, on by default for terraform 0.15.3+
+	ScratchDir                string          `mapstructure:"scratch_dir"`                 // Directory the isolated working directories of each run's executions are created in, defaults to $TMPDIR/runiac
+	KeepScratch               bool            `mapstructure:"keep_scratch"`                // Keep the working directories of the run rather than removing them once it completes
+	FailOnDestroy             bool            `mapstructure:"fail_on_destroy"`             // Fail dry runs of ARM steps whose what-if deletes resources
 	// Set at task definition creation
 	Namespace   string `mapstructure:"namespace"`                   // The namespace to use in the Terraform run.
 	Environment string `mapstructure:"environment" required:"true"` // The name of the environment (e.g. pr, nonprod, prod)
@@ -123,6 +153,29 @@ func GetConfig() (Config, error) {
 	_ = viper.BindEnv("account_id")
 	_ = viper.BindEnv("runner")
 	_ = viper.BindEnv("step_whitelist")
+	_ = viper.BindEnv("secret_keys")
+	_ = viper.BindEnv("terraform_versions_dir")
+	_ = viper.BindEnv("terraform_flavor")
+	_ = viper.BindEnv("targets")
+	_ = viper.BindEnv("replaces")
+	_ = viper.BindEnv("plugin_cache_dir")
+	_ = viper.BindEnv("plugin_cache_may_break_lock")
+	_ = viper.BindEnv("retryable_errors")
+	_ = viper.BindEnv("force_unlock")
+	_ = viper.BindEnv("lock_ledger_dir")
+	_ = viper.BindEnv("unique_external_execution_id")
+	_ = viper.BindEnv("exec_step")
+	_ = viper.BindEnv("exec_args")
+	_ = viper.BindEnv("exec_print_env")
+	_ = viper.BindEnv("state_command")
+	_ = viper.BindEnv("state_args")
+	_ = viper.BindEnv("state_backup_dir")
+	_ = viper.BindEnv("preflight")
+	_ = viper.BindEnv("lint")
+	_ = viper.BindEnv("terraform_json_ui")
+	_ = viper.BindEnv("scratch_dir")
+	_ = viper.BindEnv("keep_scratch")
+	_ = viper.BindEnv("fail_on_destroy")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -133,13 +186,19 @@ func GetConfig() (Config, error) {
 	}
 
 	conf := &Config{
-		MaxTestRetries: 2,
-		MaxRetries:     3,
-		LogLevel:       logrus.InfoLevel.String(),
-		Project:        "runiac",
-		TargetAll:      true,
+		MaxTestRetries:  2,
+		MaxRetries:      3,
+		LogLevel:        logrus.InfoLevel.String(),
+		Project:         "runiac",
+		TargetAll:       true,
+		TerraformFlavor: "terraform",
+		TerraformJSONUI: true,
 	}
-	err := viper.Unmarshal(conf)
+	err := viper.Unmarshal(conf, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		jsonStringToSliceHookFunc(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)))
 
 	if err != nil {
 		fmt.Printf("unable to decode into config struct, %v", err)
@@ -154,6 +213,30 @@ func GetConfig() (Config, error) {
 		return *conf, err
 	}
 
+	if conf.PluginCacheDir == "" {
+		conf.PluginCacheDir = defaultPluginCacheDir()
+	}
+
+	if conf.LockLedgerDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			conf.LockLedgerDir = filepath.Join(home, ".runiac", "locks")
+		}
+	}
+
+	if conf.StateBackupDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			conf.StateBackupDir = filepath.Join(home, ".runiac", "state-backups")
+		}
+	}
+
+	if conf.ScratchDir == "" {
+		conf.ScratchDir = filepath.Join(os.TempDir(), "runiac")
+	}
+
+	if conf.UniqueExternalExecutionID == "" {
+		conf.UniqueExternalExecutionID = NewRunID()
+	}
+
 	// if step whitelist is set, respect it
 	if conf.TargetAll && len(conf.StepWhitelist) > 0 {
 		conf.TargetAll = false
@@ -162,6 +245,29 @@ func GetConfig() (Config, error) {
 	return *conf, nil
 }
 
+// NewRunID generates an identifier for a run, ordered by the time the run started
+func NewRunID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405Z"), hex.EncodeToString(b))
+}
+
+// defaultPluginCacheDir returns the plugin cache directory terraform is configured with, otherwise the conventional
+// directory created by runiac containers
+func defaultPluginCacheDir() string {
+	if dir := os.Getenv("TF_PLUGIN_CACHE_DIR"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".terraform.d", "plugin-cache")
+}
+
 func InputValidation(sl validator.StructLevel) {
 	input := sl.Current().Interface().(Config)
 
@@ -176,4 +282,64 @@ func InputValidation(sl validator.StructLevel) {
 	if input.Runner != "terraform" && input.Runner != "arm" {
 		sl.ReportError(input.Runner, "runner", "runner", "invalid-runner", "")
 	}
+
+	for _, target := range input.Targets {
+		if _, err := ParseResourceAddress(target); err != nil {
+			sl.ReportError(input.Targets, "targets", "targets", "invalid-target", target)
+		}
+	}
+
+	for _, replace := range input.Replaces {
+		if _, err := ParseResourceAddress(replace); err != nil {
+			sl.ReportError(input.Replaces, "replaces", "replaces", "invalid-replace", replace)
+		}
+	}
+
+	if input.ExecStep != "" {
+		if _, err := ParseStepAddress(input.ExecStep); err != nil {
+			sl.ReportError(input.ExecStep, "exec_step", "execStep", "invalid-exec-step", input.ExecStep)
+		}
+	}
+
+	if _, err := ParseExecArgs(input.ExecArgs); err != nil {
+		sl.ReportError(input.ExecArgs, "exec_args", "execArgs", "invalid-exec-args", input.ExecArgs)
+	}
+
+	switch input.StateCommand {
+	case "", "list", "show", "mv", "rm":
+	default:
+		sl.ReportError(input.StateCommand, "state_command", "stateCommand", "invalid-state-command", input.StateCommand)
+	}
+
+	if _, err := ParseExecArgs(input.StateArgs); err != nil {
+		sl.ReportError(input.StateArgs, "state_args", "stateArgs", "invalid-state-args", input.StateArgs)
+	}
+
+	for _, pattern := range input.RetryableErrors {
+		if _, err := regexp.Compile(pattern); err != nil {
+			sl.ReportError(input.RetryableErrors, "retryable_errors", "retryableErrors", "invalid-retryable-error", pattern)
+		}
+	}
+}
+
+// jsonStringToSliceHookFunc decodes strings holding a JSON array into string slices, e.g. RUNIAC_TARGETS passed by the
+// cli, as resource addresses may contain commas. Other strings are left to the comma separated decoding.
+func jsonStringToSliceHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf([]string{}) {
+			return data, nil
+		}
+
+		s := strings.TrimSpace(data.(string))
+		if !strings.HasPrefix(s, "[") {
+			return data, nil
+		}
+
+		values := []string{}
+		if err := json.Unmarshal([]byte(s), &values); err != nil {
+			return nil, fmt.Errorf("invalid JSON array %q: %w", s, err)
+		}
+
+		return values, nil
+	}
 }
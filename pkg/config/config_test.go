@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
@@ -36,4 +37,126 @@ func TestGetConfig_EnvironmentVariablesShouldMatch(t *testing.T) {
 	require.Equal(t, "terraform", conf.Runner)
 	require.NotEmpty(t, conf.StepWhitelist)
 	require.Equal(t, "default/default", conf.StepWhitelist[0])
+	require.True(t, conf.TerraformJSONUI)
+}
+
+func TestGetConfig_ShouldDecodeJSONArrayResourceAddresses(t *testing.T) {
+	_ = os.Setenv("RUNIAC_PRIMARY_REGION", "centralus")
+	_ = os.Setenv("RUNIAC_RUNNER", "terraform")
+	_ = os.Setenv("RUNIAC_TARGETS", `["core/dns:aws_route53_record.x[\"a,b\"]","default/network:aws_vpc.main"]`)
+	_ = os.Setenv("RUNIAC_REPLACES", "core/dns:aws_route53_record.api,default/network:aws_vpc.main")
+	defer os.Unsetenv("RUNIAC_TARGETS")
+	defer os.Unsetenv("RUNIAC_REPLACES")
+
+	conf, err := GetConfig()
+
+	require.NoError(t, err)
+	require.Equal(t, []string{`core/dns:aws_route53_record.x["a,b"]`, "default/network:aws_vpc.main"}, conf.Targets)
+	require.Equal(t, []string{"core/dns:aws_route53_record.api", "default/network:aws_vpc.main"}, conf.Replaces, "comma separated addresses should still be supported")
+}
+
+func TestGetStepConfig_ShouldReadStepConfigurationFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	_ = afero.WriteFile(fs, "/tracks/core/step1_iam/runiac.yml", []byte(`terraform_version: "~> 1.3.0"`), 0644)
+
+	conf, err := GetStepConfig(fs, "/tracks/core/step1_iam")
+
+	require.NoError(t, err)
+	require.Equal(t, "~> 1.3.0", conf.TerraformVersion)
+
+	conf, err = GetStepConfig(fs, "/tracks/core/step2_other")
+
+	require.NoError(t, err, "The step configuration file should be optional")
+	require.Equal(t, "", conf.TerraformVersion)
+}
+
+func TestParseResourceAddress(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected ResourceAddress
+		err      bool
+	}{
+		"step scoped":   {input: "default/network:aws_vpc.main", expected: ResourceAddress{StepID: "default/network", Address: "aws_vpc.main"}},
+		"region scoped": {input: "core/dns@us-east-1:module.zone.aws_route53_zone.this[\"a\"]", expected: ResourceAddress{StepID: "core/dns", Region: "us-east-1", Address: "module.zone.aws_route53_zone.this[\"a\"]"}},
+		"no address":    {input: "default/network", err: true},
+		"empty region":  {input: "default/network@:aws_vpc.main", err: true},
+		"empty step":    {input: "@us-east-1:aws_vpc.main", err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr, err := ParseResourceAddress(test.input)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, addr)
+		})
+	}
+}
+
+func TestFilterResourceAddresses_ShouldMatchStepAndRegion(t *testing.T) {
+	addresses := []string{
+		"default/network:aws_vpc.main",
+		"default/network@us-east-1:aws_subnet.a",
+		"default/network@us-west-2:aws_subnet.b",
+		"default/dns:aws_route53_zone.main",
+	}
+
+	require.Equal(t, []string{"aws_vpc.main", "aws_subnet.a"}, FilterResourceAddresses(addresses, "default/network", "us-east-1"))
+	require.Equal(t, []string{"aws_vpc.main"}, FilterResourceAddresses(addresses, "default/network", "centralus"))
+	require.Empty(t, FilterResourceAddresses(addresses, "default/other", "us-east-1"))
+}
+
+func TestParseStepAddress(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected StepAddress
+		err      bool
+	}{
+		"primary":         {input: "default/network", expected: StepAddress{StepID: "default/network"}},
+		"regional":        {input: "core/dns@us-east-1", expected: StepAddress{StepID: "core/dns", Region: "us-east-1"}},
+		"empty region":    {input: "default/network@", err: true},
+		"empty step":      {input: "@us-east-1", err: true},
+		"resource scoped": {input: "default/network:aws_vpc.main", err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr, err := ParseStepAddress(test.input)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, addr)
+		})
+	}
+}
+
+func TestParseExecArgs(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []string
+		err      bool
+	}{
+		"empty":       {input: "", expected: []string{}},
+		"arguments":   {input: `["state","mv","aws_vpc.a","aws_vpc.b"]`, expected: []string{"state", "mv", "aws_vpc.a", "aws_vpc.b"}},
+		"with commas": {input: `["console","-var","list=[\"a\",\"b\"]"]`, expected: []string{"console", "-var", `list=["a","b"]`}},
+		"not json":    {input: "state,list", err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			args, err := ParseExecArgs(test.input)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, args)
+		})
+	}
 }
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// OutputToString converts a step output value into a string representation since
+// there is no easy win for string representations for e.g. lists or maps in go.
+// Lists and maps are converted to json, other values are formatted with %v. A nil value, e.g. a secure ARM output
+// without a value, is an empty string.
+func OutputToString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	var converted string
+	defaultFormat := fmt.Sprintf("%v", value)
+	valueType := reflect.TypeOf(value).String() // Get the type as a string, e.g. []interface {} for an array
+
+	if strings.HasPrefix(valueType, "map") || strings.HasPrefix(valueType, "[]") {
+		j, _ := json.Marshal(value)
+		converted = string(j)
+	} else {
+		converted = defaultFormat
+	}
+
+	return converted
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputToString(t *testing.T) {
+	tests := []struct {
+		Value          interface{}
+		ExpectedString string
+	}{
+		{
+			Value:          nil,
+			ExpectedString: "",
+		},
+		{
+			Value:          "vpcid-123",
+			ExpectedString: "vpcid-123",
+		},
+		{
+			Value:          []interface{}{"subnet1", "subnet2", "subnet3"},
+			ExpectedString: "[\"subnet1\",\"subnet2\",\"subnet3\"]",
+		},
+		{
+			Value:          []interface{}{},
+			ExpectedString: "[]",
+		},
+		{
+			Value:          map[string]interface{}{"k1": "v1", "k2": "v2"},
+			ExpectedString: "{\"k1\":\"v1\",\"k2\":\"v2\"}",
+		},
+		{
+			Value:          map[string]map[string][]string{"AZU": {"UK": []string{"uk1", "uk2"}, "US": []string{"us1", "us2"}}, "AWS": {"UK": []string{"uk1"}}, "GCP": {"UK": []string{"uk1"}}},
+			ExpectedString: "{\"AWS\":{\"UK\":[\"uk1\"]},\"AZU\":{\"UK\":[\"uk1\",\"uk2\"],\"US\":[\"us1\",\"us2\"]},\"GCP\":{\"UK\":[\"uk1\"]}}",
+		},
+	}
+
+	for _, tc := range tests {
+		result := OutputToString(tc.Value)
+		require.Equal(t, tc.ExpectedString, result)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+// Normalized actions of a planned change
+const (
+	PlanActionNoOp    = "no-op"
+	PlanActionCreate  = "create"
+	PlanActionRead    = "read"
+	PlanActionUpdate  = "update"
+	PlanActionReplace = "replace"
+	PlanActionDelete  = "delete"
+)
+
+// PlanSummary is a runner agnostic summary of the changes planned by a step execution, e.g. for reporting, policy
+// checks and approvals. Sensitive values are masked with MaskedValue.
+type PlanSummary struct {
+	ResourceChanges []PlannedResourceChange // Changes the execution will make, including no-op changes
+	ResourceDrift   []PlannedResourceChange // Changes made outside of the step since it was last deployed
+	OutputChanges   []PlannedOutputChange
+	Add             int // Resources that will be created, including replacements
+	Change          int // Resources that will be updated in-place
+	Destroy         int // Resources that will be destroyed, including replacements
+}
+
+// HasChanges returns whether the plan changes any resource or output
+func (s PlanSummary) HasChanges() bool {
+	if s.Add > 0 || s.Change > 0 || s.Destroy > 0 {
+		return true
+	}
+
+	for _, o := range s.OutputChanges {
+		if o.Action != PlanActionNoOp {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PlannedResourceChange is a planned change of a single resource instance
+type PlannedResourceChange struct {
+	Address          string
+	PreviousAddress  string // Address of the resource before it was moved, if it was moved
+	ModuleAddress    string
+	Mode             string // managed or data
+	Type             string
+	Name             string
+	ProviderName     string
+	Action           string   // One of the PlanAction constants
+	ActionReason     string   // Why the action was planned, e.g. replace_because_cannot_update, when the runner reports it
+	ReplacePaths     []string // Attribute paths forcing the replacement, e.g. subnet_ids[0]
+	AttributeChanges []AttributeChange
+}
+
+// AttributeChange is the change of a single attribute of a resource, addressed by its path, e.g. tags.Name
+type AttributeChange struct {
+	Path      string
+	Before    interface{}
+	After     interface{}
+	Unknown   bool // The value is only known after apply
+	Sensitive bool // The values are masked
+}
+
+// PlannedOutputChange is a planned change of an output variable of the step
+type PlannedOutputChange struct {
+	Name      string
+	Action    string // One of the PlanAction constants
+	Before    interface{}
+	After     interface{}
+	Unknown   bool // The value is only known after apply
+	Sensitive bool // The values are masked
+}
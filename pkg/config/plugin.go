@@ -1,6 +1,9 @@
 package config
 
-import "github.com/sirupsen/logrus"
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
 
 // Interface RunnerPlugin describes capacilities and initializtion for runiac plugins.
 type RunnerPlugin interface {
@@ -8,3 +11,15 @@ type RunnerPlugin interface {
 	// Any user-facing output should be sent to the provided`logger` instance.
 	Initialize(logger *logrus.Entry)
 }
+
+// Interface RunPreparer describes plugins that perform one-time preparation once the steps of a run are known.
+type RunPreparer interface {
+	// PrepareRun is called once with all steps of the run before any step is executed.
+	PrepareRun(logger *logrus.Entry, steps []Step)
+}
+
+// Interface StepDetector describes plugins that recognize the directories containing their step configuration.
+type StepDetector interface {
+	// IsStepDir returns whether the directory contains configuration the runner executes, e.g. terraform files.
+	IsStepDir(fs afero.Fs, dir string) bool
+}
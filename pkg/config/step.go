@@ -3,6 +3,8 @@ package config
 import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"time"
 )
 
 type StepExecution struct {
@@ -15,7 +17,8 @@ type StepExecution struct {
 	TargetAccountID            string
 	RegionGroup                string
 	PrimaryRegion              string
-	Dir                        string
+	Dir                        string // Working directory of the execution
+	SourceDir                  string // Directory of the step, or its regional directory, within the project the working directory is created from
 	Environment                string `json:"environment"`
 	AppVersion                 string `json:"app_version"`
 	AccountID                  string `json:"account_id"`
@@ -35,6 +38,54 @@ type StepExecution struct {
 	DefaultStepOutputVariables map[string]map[string]string // Previous step output variables are available in this map. K=StepName,V=map[VarName:VarVal]
 	OptionalStepParams         map[string]string
 	RequiredStepParams         map[string]interface{}
+	StepConfig                 StepConfig
+	TerraformVersionsDir       string
+	TerraformFlavor            string   // The terraform compatible CLI to execute the step with, the step's runiac.yml takes precedence
+	Targets                    []string // Resource addresses to target within this execution
+	Replaces                   []string // Resource addresses to force replacement of within this execution
+	PluginCacheDir             string   // Provider plugin cache shared by all executions
+	PluginCacheMayBreakLock    bool     // Use the plugin cache for steps without a dependency lock file
+	RetryableErrors            []string // User configured regular expressions of runner errors to retry
+	ForceUnlock                bool     // Force unlock state locks held by a previous run of the same step and region
+	LockLedgerDir              string   // Directory recording the state lock holders of runs
+	StateBackupDir             string   // Directory of the state backups taken before every state mutation
+	TerraformJSONUI            bool     // Stream terraform's machine readable UI from plan and apply as structured events
+	RunnerBinary               string   // Path to the runner binary selected for this execution, defaults to the runner's binary on PATH
+	RunnerVersion              string   // Version of the runner binary selected for this execution, if known
+	Overrides                  []string // Override files applied to this execution, in order of precedence
+	FailOnDestroy              bool     // Fail the execution's dry run when its planned changes delete resources
+}
+
+// StepConfig represents the optional runiac.yml configuration file within a step's directory
+type StepConfig struct {
+	TerraformVersion     string `mapstructure:"terraform_version"`       // Version constraint for the terraform binary, takes precedence over required_version
+	TerraformFlavor      string `mapstructure:"terraform_flavor"`        // The terraform compatible CLI to execute the step with, overrides the global setting
+	ArmScope             string `mapstructure:"arm_scope"`               // Scope the step's template is deployed at, takes precedence over the template's $schema
+	ArmResourceGroup     string `mapstructure:"arm_resource_group"`      // Base name of the resource group of resource group scoped templates
+	ArmDeploymentMode    string `mapstructure:"arm_deployment_mode"`     // Deployment mode of resource group scoped templates, Complete or Incremental
+	ArmManagementGroupID string `mapstructure:"arm_management_group_id"` // Management group of management group scoped templates
+}
+
+// GetStepConfig reads the optional runiac.yml configuration file within a step's directory
+func GetStepConfig(fs afero.Fs, dir string) (StepConfig, error) {
+	conf := StepConfig{}
+
+	v := viper.New()
+	v.SetFs(fs)
+	v.SetConfigName("runiac")
+	v.AddConfigPath(dir)
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			// configuration file is optional
+			return conf, nil
+		}
+		return conf, err
+	}
+
+	err := v.Unmarshal(&conf)
+
+	return conf, err
 }
 
 // Step represents a delivery framework step, e.g. the executions needed to implement a track
@@ -52,6 +103,7 @@ type Step struct {
 	Output                 StepOutput
 	TestOutput             StepTestOutput
 	Runner                 Stepper
+	Config                 StepConfig
 	//runiacConfig       runiacConfig
 }
 
@@ -64,13 +116,50 @@ type StepTestOutput struct {
 
 // StepOutput represents the output of a step
 type StepOutput struct {
-	Status           DeployResult
-	RegionDeployType RegionDeployType
-	Region           string
-	StepName         string
-	StreamOutput     string
-	Err              error
-	OutputVariables  map[string]interface{}
+	Status                   DeployResult
+	RegionDeployType         RegionDeployType
+	Region                   string
+	StepName                 string
+	StreamOutput             string
+	Err                      error
+	OutputVariables          map[string]interface{}
+	SensitiveOutputVariables map[string]bool  // Output variable names flagged as sensitive by the runner. Values must never be logged or reported
+	RunnerVersion            string           // Version of the runner binary used to execute the step, if known
+	Targets                  []string         // Resource addresses the execution was limited to, a partial apply when set
+	Replaces                 []string         // Resource addresses the execution forced replacement of
+	Overrides                []string         // Override files applied to the execution, in order of precedence
+	StateLock                *StateLock       // Holder of the state lock that blocked the execution, if any
+	Diagnostics              []Diagnostic     // Errors and warnings reported by the runner, when it reports them structured
+	ResourceResults          []ResourceResult // Outcome of the change applied to each resource, when the runner reports them structured
+	Plan                     *PlanSummary     // Changes planned by the execution, when the runner reports them
+}
+
+// Diagnostic is an error or warning reported by the runner, located in the step's configuration when known
+type Diagnostic struct {
+	Severity string // error or warning
+	Summary  string
+	Detail   string
+	Address  string // Resource address the diagnostic applies to, if any
+	Filename string
+	Line     int
+}
+
+// ResourceResult is the outcome of applying a change to a single resource
+type ResourceResult struct {
+	Address        string
+	Action         string // e.g. create, update, delete
+	Errored        bool
+	ElapsedSeconds float64
+}
+
+// StateLock describes the holder of a state lock that blocked a step execution
+type StateLock struct {
+	ID            string
+	Path          string
+	Operation     string
+	Who           string
+	Created       time.Time
+	ForceUnlocked bool // Whether runiac force unlocked the lock, since it was held by a previous run
 }
 
 // TFProviderType represents a Terraform provider type
@@ -100,6 +189,51 @@ type Stepper interface {
 	ExecuteStepDestroy(execution StepExecution) (output StepOutput)
 }
 
+// StepCommand is a runner command environment prepared within a step execution, as the runner would deploy the step
+type StepCommand struct {
+	Binary string            // Runner binary to execute
+	Dir    string            // Working directory of the execution
+	Env    map[string]string // Environment of the execution, including step parameters and previous step outputs
+}
+
+// StepCommander is implemented by steppers that can prepare a step execution to run arbitrary runner commands in it,
+// e.g. for debugging a step without deploying it
+type StepCommander interface {
+	// PrepareStepCommand prepares the execution as ExecuteStep would, e.g. initializing the backend, without deploying it
+	PrepareStepCommand(execution StepExecution) (command StepCommand, err error)
+	// ReadStepOutputs returns the output variables of the execution's last deployment without deploying it
+	ReadStepOutputs(execution StepExecution) (output StepOutput)
+}
+
+// StateManager is implemented by steppers that can inspect and modify the state of a step execution
+type StateManager interface {
+	// ListState returns the resource addresses in the execution's state
+	ListState(execution StepExecution) (addresses []string, err error)
+	// ShowState returns the attributes of a resource in the execution's state
+	ShowState(execution StepExecution, address string) (resource string, err error)
+	// RemoveState backs up the execution's state and removes the resources from it
+	RemoveState(execution StepExecution, addresses []string) error
+	// MoveState backs up the states of both executions and moves a resource from the source execution's state to the
+	// destination execution's state, which may be the same execution
+	MoveState(source StepExecution, sourceAddress string, destination StepExecution, destinationAddress string) error
+}
+
+// PreflightIssue is a failed pre-flight check of a step execution's directory
+type PreflightIssue struct {
+	StepID           string
+	RegionDeployType RegionDeployType
+	Dir              string
+	Check            string // The failed check, e.g. fmt or validate
+	Message          string
+}
+
+// PreflightChecker is implemented by steppers that can check a step before any step is deployed, e.g. for syntax
+// errors, without contacting the step's backend
+type PreflightChecker interface {
+	// CheckStep checks the execution's directory, returning an issue for every failed check
+	CheckStep(execution StepExecution) []PreflightIssue
+}
+
 type DeployResult int
 
 const (
@@ -113,3 +247,6 @@ const (
 func (d DeployResult) String() string {
 	return [...]string{"FAIL", "SUCCESS", "UNSTABLE", "SKIPPED"}[d]
 }
+
+// MaskedValue replaces the value of a sensitive variable wherever it would otherwise be logged or reported
+const MaskedValue = "(sensitive value)"
@@ -0,0 +1,186 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedValue replaces registered secrets in log entries
+const RedactedValue = "***"
+
+// minSecretLength guards against redacting trivially short values (e.g. "1" or "true") everywhere they appear in logs
+const minSecretLength = 4
+
+// KnownSecretEnvVars are environment variables whose values are always treated as secrets
+var KnownSecretEnvVars = []string{
+	"ARM_CLIENT_SECRET",
+	"ARM_ACCESS_KEY",
+	"ARM_SAS_TOKEN",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"GOOGLE_CREDENTIALS",
+}
+
+// SecretEnvVarPattern matches environment variable names that are treated as secrets
+var SecretEnvVarPattern = regexp.MustCompile(`(?i)(SECRET|PASSWORD|PASSWD|TOKEN|ACCESS_KEY|PRIVATE_KEY|CREDENTIALS)`)
+
+// Redactor is a registry of secret values that must never be written to logs
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets map[string]struct{}
+	ordered []string // secrets sorted longest first so overlapping secrets are fully redacted
+}
+
+// NewRedactor returns an empty redaction registry
+func NewRedactor() *Redactor {
+	return &Redactor{
+		secrets: map[string]struct{}{},
+	}
+}
+
+// DefaultRedactor is the process wide registry used by the logging pipeline
+var DefaultRedactor = NewRedactor()
+
+// RegisterSecret adds a value to the default registry
+func RegisterSecret(value string) {
+	DefaultRedactor.Register(value)
+}
+
+// RegisterSecretValue adds every string within a value to the default registry
+func RegisterSecretValue(value interface{}) {
+	DefaultRedactor.RegisterValue(value)
+}
+
+// Redact replaces all secrets in the default registry found in s
+func Redact(s string) string {
+	return DefaultRedactor.Redact(s)
+}
+
+// Register adds a value to the registry. Blank and trivially short values are ignored.
+func (r *Redactor) Register(value string) {
+	value = strings.TrimSpace(value)
+	if len(value) < minSecretLength {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.secrets[value]; ok {
+		return
+	}
+
+	r.secrets[value] = struct{}{}
+	r.ordered = append(r.ordered, value)
+	sort.SliceStable(r.ordered, func(i, j int) bool {
+		return len(r.ordered[i]) > len(r.ordered[j])
+	})
+}
+
+// RegisterValue adds every string within a value, e.g. a sensitive output decoded from json, to the registry. Maps
+// and slices are walked, so nested strings are redacted wherever they are logged, including within json.
+func (r *Redactor) RegisterValue(value interface{}) {
+	switch v := value.(type) {
+	case string:
+		r.Register(v)
+
+		// strings logged within json are escaped, e.g. the newlines of a private key
+		if escaped, err := json.Marshal(v); err == nil {
+			r.Register(strings.Trim(string(escaped), `"`))
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			r.RegisterValue(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			r.RegisterValue(item)
+		}
+	}
+}
+
+// RegisterFromEnv registers the values of known secret environment variables, variables matching
+// SecretEnvVarPattern and any additional configured keys. environ is in the form of os.Environ().
+func (r *Redactor) RegisterFromEnv(environ []string, secretKeys []string) {
+	known := map[string]bool{}
+	for _, k := range KnownSecretEnvVars {
+		known[k] = true
+	}
+	for _, k := range secretKeys {
+		known[k] = true
+	}
+
+	for _, kv := range environ {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+
+		if known[pair[0]] || SecretEnvVarPattern.MatchString(pair[0]) {
+			r.Register(pair[1])
+		}
+	}
+}
+
+// Redact replaces all registered secrets found in s with RedactedValue
+func (r *Redactor) Redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, secret := range r.ordered {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, RedactedValue)
+		}
+	}
+
+	return s
+}
+
+// RedactionHook is a logrus hook that redacts registered secrets from the message and fields of every entry.
+// Hooks fire before formatting, so this applies to all formatters and to streamed command output.
+type RedactionHook struct {
+	Redactor *Redactor
+}
+
+// NewRedactionHook returns a hook backed by the given registry
+func NewRedactionHook(r *Redactor) *RedactionHook {
+	return &RedactionHook{Redactor: r}
+}
+
+// Levels implements logrus.Hook
+func (h *RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *RedactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.Redactor.Redact(entry.Message)
+
+	// entry.Data is shared with the parent entry, copy it before redacting
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		switch val := v.(type) {
+		case string:
+			data[k] = h.Redactor.Redact(val)
+		case error:
+			if redacted := h.Redactor.Redact(val.Error()); redacted != val.Error() {
+				data[k] = redacted
+			} else {
+				data[k] = val
+			}
+		case fmt.Stringer:
+			data[k] = h.Redactor.Redact(val.String())
+		default:
+			data[k] = val
+		}
+	}
+	entry.Data = data
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_RegisterFromEnv(t *testing.T) {
+	r := NewRedactor()
+
+	r.RegisterFromEnv([]string{
+		"ARM_CLIENT_SECRET=super-secret-value",
+		"DB_PASSWORD=hunter22",
+		"MY_CUSTOM_KEY=custom-value",
+		"PATH=/usr/bin",
+		"SHORT_TOKEN=ab",
+	}, []string{"MY_CUSTOM_KEY"})
+
+	require.Equal(t, "secret=*** password=*** custom=*** path=/usr/bin short=ab",
+		r.Redact("secret=super-secret-value password=hunter22 custom=custom-value path=/usr/bin short=ab"))
+}
+
+func TestRedactor_ShouldRedactLongestSecretFirst(t *testing.T) {
+	r := NewRedactor()
+	r.Register("secret")
+	r.Register("secret-with-suffix")
+
+	require.Equal(t, "value: ***", r.Redact("value: secret-with-suffix"))
+}
+
+func TestRedactor_RegisterValue_ShouldRegisterEveryNestedString(t *testing.T) {
+	r := NewRedactor()
+
+	r.RegisterValue(map[string]interface{}{
+		"password": "hunter22",
+		"hosts":    []interface{}{"db.internal.example", 5432},
+		"key":      "-----BEGIN KEY-----\nsecret\n-----END KEY-----",
+	})
+
+	require.Equal(t, `{"hosts":["***",5432],"password":"***"}`, r.Redact(`{"hosts":["db.internal.example",5432],"password":"hunter22"}`))
+	require.Equal(t, `{"key":"***"}`, r.Redact(`{"key":"-----BEGIN KEY-----\nsecret\n-----END KEY-----"}`))
+	require.Equal(t, "5432", r.Redact("5432"))
+}
+
+func TestRedactionHook_ShouldRedactBeforeFormatting(t *testing.T) {
+	r := NewRedactor()
+	r.Register("hunter22")
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(NewRedactionHook(r))
+
+	entry := logger.WithField("password", "hunter22")
+	entry.WithError(errors.New("auth failed for hunter22")).Error("running command -var password=hunter22")
+
+	require.NotContains(t, buf.String(), "hunter22")
+	require.Equal(t, "hunter22", entry.Data["password"], "Redaction should not modify the parent entry's fields")
+}
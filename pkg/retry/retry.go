@@ -5,6 +5,11 @@ package retry
 import (
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -36,8 +41,165 @@ func DoWithRetry(actionDescription string, maxRetries int, sleepBetweenRetries t
 type MaxRetriesExceeded struct {
 	Description string
 	MaxRetries  int
+	Underlying  error // The error of the final attempt, if known
 }
 
 func (err MaxRetriesExceeded) Error() string {
+	if err.Underlying != nil {
+		return fmt.Sprintf("'%s' unsuccessful after %d retries: %v", err.Description, err.MaxRetries, err.Underlying)
+	}
 	return fmt.Sprintf("'%s' unsuccessful after %d retries", err.Description, err.MaxRetries)
 }
+
+func (err MaxRetriesExceeded) Unwrap() error {
+	return err.Underlying
+}
+
+// Backoff computes exponentially increasing delays between retries, randomized by a jitter fraction so concurrent
+// executions retrying the same transient error do not retry in lockstep.
+type Backoff struct {
+	Initial    time.Duration // Delay before the first retry
+	Max        time.Duration // Upper bound of any delay, before jitter
+	Multiplier float64       // Growth factor of the delay for each retry
+	Jitter     float64       // Fraction of the delay that is randomized, e.g. 0.2 is +/- 20%
+}
+
+// DefaultBackoff is the backoff used for retrying runner commands
+var DefaultBackoff = Backoff{
+	Initial:    5 * time.Second,
+	Max:        2 * time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+var jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+var jitterMutex sync.Mutex
+
+// Delay returns the delay before the retry following the given zero based attempt
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt))
+
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		jitterMutex.Lock()
+		r := jitterRand.Float64()
+		jitterMutex.Unlock()
+
+		delay += delay * b.Jitter * (2*r - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// RetryableError is a compiled retryable error pattern and the reason displayed when it matches
+type RetryableError struct {
+	Pattern *regexp.Regexp
+	Reason  string
+}
+
+// RetryableErrors are retryable error patterns, in sorted order of their regular expressions
+type RetryableErrors []RetryableError
+
+// CompileRetryableErrors compiles a retryable errors catalog. The keys of the catalog are regular expressions and the
+// values the reason displayed when they match.
+func CompileRetryableErrors(catalog map[string]string) (RetryableErrors, error) {
+	patterns := make([]string, 0, len(catalog))
+	for pattern := range catalog {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	compiled := make(RetryableErrors, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retryable error pattern %s: %w", pattern, err)
+		}
+
+		compiled = append(compiled, RetryableError{Pattern: re, Reason: catalog[pattern]})
+	}
+
+	return compiled, nil
+}
+
+// MatchRetryableError returns the reason of the first retryable error pattern matching the text
+func MatchRetryableError(retryableErrors RetryableErrors, text string) (reason string, ok bool) {
+	for _, retryableError := range retryableErrors {
+		if retryableError.Pattern.MatchString(text) {
+			return retryableError.Reason, true
+		}
+	}
+
+	return "", false
+}
+
+// DoWithRetryableErrors runs the specified action. If it returns an error whose message or output matches one of
+// retryableErrors, sleep for the backoff delay and try again, up to a maximum of maxRetries retries. Any other error
+// is returned immediately as a FatalError. If maxRetries is exceeded, return a MaxRetriesExceeded error.
+func DoWithRetryableErrors(actionDescription string, retryableErrors RetryableErrors, maxRetries int, backoff Backoff, logger *logrus.Entry, action func(attempt int) (string, error)) (string, error) {
+	var out string
+	var err error
+
+	for i := 0; i <= maxRetries; i++ {
+		logger.Infof(actionDescription)
+
+		out, err = action(i)
+		if err == nil {
+			return out, nil
+		}
+
+		reason, retryable := MatchRetryableError(retryableErrors, fmt.Sprintf("%s\n%s", out, err.Error()))
+
+		if !retryable {
+			logger.WithError(err).Errorf("%s returned an error that is not retryable.", actionDescription)
+			return out, FatalError{Underlying: err}
+		}
+
+		// don't sleep after the final retry attempt
+		if i < maxRetries {
+			delay := backoff.Delay(i)
+			logger.WithError(err).WithField("retryReason", reason).Warningf("%s returned a retryable error: %s. Sleeping for %s and will try again. Retry Count: %v.", actionDescription, reason, delay, i)
+			time.Sleep(delay)
+		} else {
+			logger.WithError(err).WithField("retryReason", reason).Warningf("%s returned a retryable error: %s. Retry Count: %v.", actionDescription, reason, i)
+		}
+	}
+
+	return out, MaxRetriesExceeded{Description: actionDescription, MaxRetries: maxRetries, Underlying: err}
+}
+
+// FatalError is an error that should not be retried
+type FatalError struct {
+	Underlying error
+}
+
+func (err FatalError) Error() string {
+	return fmt.Sprintf("FatalError{Underlying: %v}", err.Underlying)
+}
+
+func (err FatalError) Unwrap() error {
+	return err.Underlying
+}
+
+// UserRetryableErrorReason is the reason displayed when a user configured retryable error pattern is matched
+const UserRetryableErrorReason = "Matched a configured retryable error pattern."
+
+// MergeRetryableErrors returns a runner's retryable errors catalog extended with user configured patterns, compiled.
+// An invalid pattern is returned as an error rather than never matching.
+func MergeRetryableErrors(catalog map[string]string, patterns []string) (RetryableErrors, error) {
+	merged := make(map[string]string, len(catalog)+len(patterns))
+
+	for pattern, reason := range catalog {
+		merged[pattern] = reason
+	}
+
+	for _, pattern := range patterns {
+		merged[pattern] = UserRetryableErrorReason
+	}
+
+	return CompileRetryableErrors(merged)
+}
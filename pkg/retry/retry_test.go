@@ -37,3 +37,102 @@ func TestDoRetry_ShouldParseS3Correctly(t *testing.T) {
 		return errors.New("error")
 	})
 }
+
+func TestBackoff_ShouldGrowExponentiallyUpToMax(t *testing.T) {
+	t.Parallel()
+
+	backoff := retry.Backoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+
+	require.Equal(t, time.Second, backoff.Delay(0))
+	require.Equal(t, 2*time.Second, backoff.Delay(1))
+	require.Equal(t, 4*time.Second, backoff.Delay(2))
+	require.Equal(t, 5*time.Second, backoff.Delay(3), "delay should be capped at max")
+}
+
+func TestBackoff_ShouldJitterWithinBounds(t *testing.T) {
+	t.Parallel()
+
+	backoff := retry.Backoff{Initial: 10 * time.Second, Multiplier: 2, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		delay := backoff.Delay(0)
+		require.GreaterOrEqual(t, int64(delay), int64(8*time.Second))
+		require.LessOrEqual(t, int64(delay), int64(12*time.Second))
+	}
+}
+
+func TestDoWithRetryableErrors_ShouldFailImmediatelyOnNonRetryableError(t *testing.T) {
+	t.Parallel()
+	attempts := 0
+
+	_, err := retry.DoWithRetryableErrors("terraform plan", mustCompile(t, map[string]string{"(?i)i/o timeout": "Network request timed out."}), 3, retry.Backoff{}, logger, func(attempt int) (string, error) {
+		attempts++
+		return "Error: Invalid reference", errors.New("exit status 1")
+	})
+
+	require.Equal(t, 1, attempts, "should not retry a non-retryable error")
+	require.IsType(t, retry.FatalError{}, err)
+}
+
+func TestDoWithRetryableErrors_ShouldRetryRetryableErrorMatchedInOutput(t *testing.T) {
+	t.Parallel()
+	attempts := 0
+
+	_, err := retry.DoWithRetryableErrors("terraform init", mustCompile(t, map[string]string{"(?i)i/o timeout": "Network request timed out."}), 2, retry.Backoff{Initial: time.Millisecond}, logger, func(attempt int) (string, error) {
+		require.Equal(t, attempts, attempt, "attempt should increment")
+		attempts++
+		return "Error: dial tcp: i/o timeout", errors.New("exit status 1")
+	})
+
+	require.Equal(t, 3, attempts)
+	require.IsType(t, retry.MaxRetriesExceeded{}, err)
+	require.Contains(t, err.Error(), "exit status 1")
+}
+
+func TestDoWithRetryableErrors_ShouldReturnOutputOnSuccessAfterRetry(t *testing.T) {
+	t.Parallel()
+
+	out, err := retry.DoWithRetryableErrors("terraform init", mustCompile(t, map[string]string{"TooManyRequests": "Rate limited."}), 2, retry.Backoff{Initial: time.Millisecond}, logger, func(attempt int) (string, error) {
+		if attempt == 0 {
+			return "", errors.New("TooManyRequests")
+		}
+		return "ok", nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "ok", out)
+}
+
+func TestMergeRetryableErrors_ShouldAddUserPatterns(t *testing.T) {
+	t.Parallel()
+
+	merged, err := retry.MergeRetryableErrors(map[string]string{"catalog": "Catalog reason."}, []string{"custom.*error"})
+	require.NoError(t, err)
+
+	reason, ok := retry.MatchRetryableError(merged, "a custom policy error")
+	require.True(t, ok)
+	require.Equal(t, retry.UserRetryableErrorReason, reason)
+
+	reason, ok = retry.MatchRetryableError(merged, "catalog")
+	require.True(t, ok)
+	require.Equal(t, "Catalog reason.", reason)
+
+	_, ok = retry.MatchRetryableError(merged, "syntax error")
+	require.False(t, ok)
+}
+
+func TestMergeRetryableErrors_ShouldRejectInvalidPatterns(t *testing.T) {
+	t.Parallel()
+
+	_, err := retry.MergeRetryableErrors(map[string]string{"catalog": "Catalog reason."}, []string{"custom(error"})
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "custom(error")
+}
+
+func mustCompile(t *testing.T, catalog map[string]string) retry.RetryableErrors {
+	compiled, err := retry.CompileRetryableErrors(catalog)
+	require.NoError(t, err)
+
+	return compiled
+}
@@ -22,7 +22,8 @@ type Command struct {
 	OutputMaxLineSize int               // The max line size of stdout and stderr (in bytes)
 	Logger            *logrus.Entry
 	NonInteractive    bool
-	SensitiveArgs     bool // If true, will not log the arguments to the command
+	SensitiveArgs     bool                   // If true, will not log the arguments to the command
+	OutputHandler     func(line string) bool // Handles each streamed stdout line, the line is logged unless it returns true
 }
 
 // RunCommand runs a shell command and redirects its stdout and stderr to the stdout of the atomic script itself.
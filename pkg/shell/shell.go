@@ -63,6 +63,38 @@ func RunShellCommandAndGetOutput(command Command) (string, error) {
 	return string(out), errors.WithStackTrace(err)
 }
 
+// Run the specified shell command with the specified arguments. Return only its stdout as a string, stderr is included
+// in the returned error. This is used for commands with machine readable output, e.g. json, where wrappers may log to stderr.
+func RunShellCommandAndGetStdout(command Command) (string, error) {
+	if command.SensitiveArgs {
+		command.Logger.Infof("Running command: %s (args redacted)", command.Command)
+	} else {
+		command.Logger.Infof("Running command: %s %s", command.Command, strings.Join(command.Args, " "))
+	}
+
+	cmd := exec.Command(command.Command, command.Args...)
+
+	cmd.Stdin = os.Stdin
+	cmd.Dir = command.WorkingDir
+
+	if len(command.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range command.Env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return string(out), errors.WithStackTrace(fmt.Errorf("%v: %s", err, stderr.String()))
+	}
+
+	return string(out), nil
+}
+
 func KeysStringString(m map[string]string) string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -135,7 +167,9 @@ func readStdoutAndStderr(stdout io.ReadCloser, stderr io.ReadCloser, command Com
 	for {
 		if stdoutScanner.Scan() {
 			text := stdoutScanner.Text()
-			command.Logger.Println(text)
+			if command.OutputHandler == nil || !command.OutputHandler(text) {
+				command.Logger.Println(text)
+			}
 			allOutput = append(allOutput, text)
 		} else if stderrScanner.Scan() {
 			text := stderrScanner.Text()
@@ -172,3 +206,17 @@ func CommandInstalledE(command string) error {
 	}
 	return nil
 }
+
+// Quote quotes s as a single POSIX shell word, e.g. for printing an environment or arguments a shell can evaluate
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteArgs quotes and joins args, so a shell splits them back into the same arguments
+func QuoteArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, Quote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
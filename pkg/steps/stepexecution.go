@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/optum/runiac/pkg/cloudaccountdeployment"
 	"github.com/optum/runiac/pkg/config"
-	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
 	"github.com/otiai10/copy"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
@@ -29,6 +28,7 @@ func NewExecution(s config.Step, logger *logrus.Entry, fs afero.Fs, regionDeploy
 		StepID:                     s.ID,
 		Namespace:                  s.DeployConfig.Namespace,
 		Dir:                        s.Dir,
+		SourceDir:                  s.Dir,
 		DeploymentRing:             s.DeployConfig.DeploymentRing,
 		DryRun:                     s.DeployConfig.DryRun,
 		MaxRetries:                 s.DeployConfig.MaxRetries,
@@ -39,6 +39,19 @@ func NewExecution(s config.Step, logger *logrus.Entry, fs afero.Fs, regionDeploy
 		UniqueExternalExecutionID:  s.DeployConfig.UniqueExternalExecutionID,
 		RegionGroups:               s.DeployConfig.RegionGroups,
 		SelfDestroy:                s.DeployConfig.SelfDestroy,
+		StepConfig:                 s.Config,
+		TerraformVersionsDir:       s.DeployConfig.TerraformVersionsDir,
+		TerraformFlavor:            s.DeployConfig.TerraformFlavor,
+		Targets:                    config.FilterResourceAddresses(s.DeployConfig.Targets, s.ID, region),
+		Replaces:                   config.FilterResourceAddresses(s.DeployConfig.Replaces, s.ID, region),
+		PluginCacheDir:             s.DeployConfig.PluginCacheDir,
+		PluginCacheMayBreakLock:    s.DeployConfig.PluginCacheMayBreakLock,
+		RetryableErrors:            s.DeployConfig.RetryableErrors,
+		ForceUnlock:                s.DeployConfig.ForceUnlock,
+		LockLedgerDir:              s.DeployConfig.LockLedgerDir,
+		StateBackupDir:             s.DeployConfig.StateBackupDir,
+		TerraformJSONUI:            s.DeployConfig.TerraformJSONUI,
+		FailOnDestroy:              s.DeployConfig.FailOnDestroy,
 		Logger: logger.WithFields(logrus.Fields{
 			"step":            s.Name,
 			"stepProgression": s.ProgressionLevel,
@@ -63,8 +76,9 @@ func ExecuteStep(stepper config.Stepper, exec config.StepExecution) config.StepO
 	//	}
 	//}
 
-	exec.Logger.Debugf("%v", exec.RequiredStepParams)
-	exec.Logger.Debugf("%v", exec.OptionalStepParams)
+	// only log parameter keys, values may contain sensitive outputs from previous steps
+	exec.Logger.Debugf("required step params: %s", KeysStringInterface(exec.RequiredStepParams))
+	exec.Logger.Debugf("optional step params: %s", KeysString(exec.OptionalStepParams))
 
 	output := stepper.ExecuteStep(exec)
 	postStep(exec, output)
@@ -87,9 +101,25 @@ func InitExecution(s config.Step, logger *logrus.Entry, fs afero.Fs,
 	config.StepExecution, error) {
 	exec := NewExecution(s, logger, fs, regionDeployType, region, defaultStepOutputVariables)
 
-	// set and create execution directory to enable safe concurrency
+	// regional executions run the step's regional directory
 	if exec.RegionDeployType == config.RegionalRegionDeployType {
-		regionalDir := filepath.Join(s.Dir, "regional")
+		exec.SourceDir = filepath.Join(s.Dir, "regional")
+	}
+
+	// set and create an isolated working directory for every execution to enable safe concurrency and keep the source
+	// tree untouched
+	if s.DeployConfig.ScratchDir != "" {
+		dir, err := CreateWorkingDir(exec.Fs, s.DeployConfig, exec, exec.SourceDir)
+
+		if err != nil {
+			exec.Logger.WithError(err).Errorf("Unable to create the working directory of %s", exec.SourceDir)
+			return exec, err
+		}
+
+		exec.Logger.Debugf("Executing %s in %s", exec.SourceDir, dir)
+
+		exec.Dir = dir
+	} else if exec.RegionDeployType == config.RegionalRegionDeployType {
 		execRegionalDir := filepath.Join(s.Dir, fmt.Sprintf("regional-%s", exec.Region))
 		err := exec.Fs.MkdirAll(execRegionalDir, 0700)
 
@@ -100,7 +130,7 @@ func InitExecution(s config.Step, logger *logrus.Entry, fs afero.Fs,
 
 		exec.Logger.Infof("Copying %s regional to %s", exec.Region, execRegionalDir)
 
-		err = copy.Copy(regionalDir, execRegionalDir)
+		err = copy.Copy(exec.SourceDir, execRegionalDir)
 
 		if err != nil {
 			exec.Logger.WithError(err).Error(err)
@@ -147,7 +177,7 @@ func InitExecution(s config.Step, logger *logrus.Entry, fs afero.Fs,
 	// TODO: find a better way to handle this that doesn't rely on re-calling this method for tests
 	if s.Output.OutputVariables != nil {
 		for k, v := range s.Output.OutputVariables {
-			params[k] = terraform.OutputToString(v)
+			params[k] = config.OutputToString(v)
 		}
 	} else {
 		cloudaccountdeployment.RecordStepStart(exec.Logger, exec.AccountID, exec.TrackName, exec.StepName, exec.RegionDeployType.String(), exec.Region, exec.DryRun, "", exec.AppVersion, s.DeployConfig.UniqueExternalExecutionID, "", "", exec.Project, s.DeployConfig.RegionalRegions)
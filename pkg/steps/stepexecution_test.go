@@ -31,8 +31,11 @@ func TestNewExecution_ShouldSetFields(t *testing.T) {
 			UniqueExternalExecutionID: "stubExecutionID",
 			MaxRetries:                3,
 			MaxTestRetries:            2,
+			Targets:                   []string{"stubTrackName/stubName@region:aws_vpc.main", "stubTrackName/stubName@other:aws_vpc.other"},
+			Replaces:                  []string{"stubTrackName/stubName:aws_instance.main"},
 		},
 		TrackName: "stubTrackName",
+		ID:        "stubTrackName/stubName",
 	}
 	// act
 	mock := NewExecution(stubStep, logger, fs, stubRegionalDeployType, stubRegion, map[string]map[string]string{})
@@ -50,5 +53,7 @@ func TestNewExecution_ShouldSetFields(t *testing.T) {
 	require.Equal(t, stubStep.DeployConfig.RegionalRegions, mock.RegionGroupRegions, "RegionGroupRegions should match stub value")
 	require.Equal(t, stubStep.DeployConfig.MaxRetries, mock.MaxRetries, "MaxRetries should match stub value")
 	require.Equal(t, stubStep.DeployConfig.MaxTestRetries, mock.MaxTestRetries, "MaxTestRetries should match stub value")
+	require.Equal(t, []string{"aws_vpc.main"}, mock.Targets, "Targets should only include the step's region")
+	require.Equal(t, []string{"aws_instance.main"}, mock.Replaces, "Replaces should include the step's unscoped region")
 
 }
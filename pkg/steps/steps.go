@@ -41,3 +41,19 @@ func KeysStringMap(m map[string]map[string]string) string {
 	}
 	return "[" + strings.Join(keys, ", ") + "]"
 }
+
+func KeysString(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return "[" + strings.Join(keys, ", ") + "]"
+}
+
+func KeysStringInterface(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return "[" + strings.Join(keys, ", ") + "]"
+}
@@ -0,0 +1,214 @@
+package steps
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+var invalidWorkingDirChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// runiacArtifacts are the files and directories left in step directories by runiac and its runners, which are never
+// copied into an execution's working directory
+var runiacArtifacts = []string{
+	".temp",
+	".terraform",
+	"terraform.tfstate",
+	"terraform.tfstate.backup",
+	"terraform.tfstate.d",
+}
+
+// legacyArtifact matches the regional working directories and plans previous versions of runiac created within steps
+var legacyArtifact = regexp.MustCompile(`^(regional-.+|.+tfplan)$`)
+
+// RunScratchDir returns the run scoped scratch area the working directories of the run's executions are created in
+func RunScratchDir(cfg config.Config) string {
+	return filepath.Join(cfg.ScratchDir, cfg.UniqueExternalExecutionID)
+}
+
+// CleanupScratch removes the run's scratch area, unless it is configured to be kept for troubleshooting
+func CleanupScratch(fs afero.Fs, logger *logrus.Entry, cfg config.Config) {
+	if cfg.ScratchDir == "" {
+		return
+	}
+
+	dir := RunScratchDir(cfg)
+
+	if cfg.KeepScratch {
+		logger.Infof("Keeping the working directories of the run in %s", dir)
+		return
+	}
+
+	if err := fs.RemoveAll(dir); err != nil {
+		logger.WithError(err).Warnf("Unable to remove the working directories of the run in %s", dir)
+	}
+}
+
+// CreateWorkingDir creates an isolated working directory for an execution of the source directory within the run's
+// scratch area. The source directory is copied, excluding runiac artifacts, to the same path relative to the current
+// directory, and every other entry along that path is linked, so relative references such as module sources resolve
+// as they would in the source tree. Returns the working directory of the execution.
+func CreateWorkingDir(fs afero.Fs, cfg config.Config, exec config.StepExecution, sourceDir string) (string, error) {
+	runDir := RunScratchDir(cfg)
+
+	if err := fs.MkdirAll(runDir, 0700); err != nil {
+		return "", err
+	}
+
+	name := invalidWorkingDirChars.ReplaceAllString(fmt.Sprintf("%s-%s-%s-%s", exec.TrackName, exec.StepName, exec.RegionDeployType, exec.Region), "_")
+
+	root, err := afero.TempDir(fs, runDir, name+"-")
+	if err != nil {
+		return "", err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	src, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(cwd, src)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		// the source is not within the current directory, so only the source directory itself is copied
+		cwd, rel = filepath.Dir(src), filepath.Base(src)
+	}
+
+	parent, dst := cwd, root
+
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if err = fs.MkdirAll(dst, 0700); err != nil {
+			return "", err
+		}
+
+		entries, err := afero.ReadDir(fs, parent)
+		if err != nil {
+			return "", err
+		}
+
+		for _, entry := range entries {
+			if entry.Name() == part {
+				continue
+			}
+
+			if err = linkEntry(fs, filepath.Join(parent, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return "", err
+			}
+		}
+
+		parent, dst = filepath.Join(parent, part), filepath.Join(dst, part)
+	}
+
+	err = copyTree(fs, src, dst, func(path string) bool {
+		return isRuniacArtifact(fs, src, path)
+	})
+
+	return dst, err
+}
+
+// linkEntry links the entry into the working directory. File systems without symbolic links, e.g. in memory file
+// systems, get a copy of the entry instead.
+func linkEntry(fs afero.Fs, src string, dst string) error {
+	if _, ok := fs.(*afero.OsFs); ok {
+		return os.Symlink(src, dst)
+	}
+
+	return copyTree(fs, src, dst, func(string) bool { return false })
+}
+
+// copyTree copies the source file or directory to the destination, except the paths to skip. Symbolic links are
+// recreated rather than followed.
+func copyTree(fs afero.Fs, src string, dst string, skip func(path string) bool) error {
+	return afero.Walk(fs, src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if skip(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return fs.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(fs, path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// copyFile copies the contents of the source file to the destination file, created with the given permissions
+func copyFile(fs afero.Fs, src string, dst string, perm os.FileMode) error {
+	in, err := fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := fs.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// isRuniacArtifact returns whether the path within the source directory was left by a previous execution in place,
+// including the override files copied from the override directory
+func isRuniacArtifact(fs afero.Fs, sourceDir string, path string) bool {
+	if filepath.Dir(path) != sourceDir {
+		return false
+	}
+
+	name := filepath.Base(path)
+
+	for _, artifact := range runiacArtifacts {
+		if name == artifact {
+			return true
+		}
+	}
+
+	if legacyArtifact.MatchString(name) {
+		return true
+	}
+
+	if strings.HasSuffix(name, "_override.tf") || name == "override.tf" {
+		if _, err := fs.Stat(filepath.Join(sourceDir, "override", name)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, fs afero.Fs, path string) {
+	require.NoError(t, fs.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, afero.WriteFile(fs, path, []byte(path), 0644))
+}
+
+func TestCreateWorkingDir_ShouldIsolateExecutionFromSourceTree(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	// the source directory is resolved relative to the current directory
+	project, err := os.Getwd()
+	require.NoError(t, err)
+
+	scratch := "/scratch"
+	stepDir := filepath.Join("tracks", "core", "step1_network")
+
+	for _, f := range []string{
+		"modules/vpc/main.tf",
+		"tracks/core/step1_network/main.tf",
+		"tracks/core/step1_network/override/override.tf",
+		"tracks/core/step1_network/override.tf",
+		"tracks/core/step1_network/.terraform/environment",
+		"tracks/core/step1_network/terraform.tfstate",
+		"tracks/core/step1_network/regional-us-east-1/main.tf",
+		"tracks/core/step1_network/regional/main.tf",
+		"tracks/core/step1_network/tests/tests.test",
+		"tracks/core/step2_dns/main.tf",
+	} {
+		writeTestFile(t, fs, filepath.Join(project, f))
+	}
+
+	cfg := config.Config{ScratchDir: scratch, UniqueExternalExecutionID: "run1"}
+	exec := config.StepExecution{TrackName: "core", StepName: "network", RegionDeployType: config.PrimaryRegionDeployType, Region: "us-east-1"}
+
+	// act
+	dir, err := CreateWorkingDir(fs, cfg, exec, stepDir)
+	require.NoError(t, err)
+
+	other, err := CreateWorkingDir(fs, cfg, exec, stepDir)
+	require.NoError(t, err)
+
+	// assert
+	require.NotEqual(t, dir, other, "every execution should have its own working directory")
+	require.Equal(t, stepDir, dir[len(dir)-len(stepDir):], "the working directory should mirror the step's path")
+	require.True(t, filepath.HasPrefix(dir, filepath.Join(scratch, "run1")), "the working directory should be in the run's scratch area")
+
+	for _, f := range []string{"main.tf", "override/override.tf", "regional/main.tf", "tests/tests.test", "../../../modules/vpc/main.tf", "../step2_dns/main.tf"} {
+		_, err = fs.Stat(filepath.Join(dir, f))
+		require.NoError(t, err, "%s should be available in the working directory", f)
+	}
+
+	for _, f := range []string{"override.tf", ".terraform", "terraform.tfstate", "regional-us-east-1"} {
+		_, err = fs.Stat(filepath.Join(dir, f))
+		require.True(t, os.IsNotExist(err), "%s should not be copied into the working directory", f)
+	}
+
+	writeTestFile(t, fs, filepath.Join(dir, "tfplan"))
+
+	_, err = fs.Stat(filepath.Join(project, stepDir, "tfplan"))
+	require.True(t, os.IsNotExist(err), "the source tree should be untouched")
+}
+
+func TestCleanupScratch_ShouldRemoveRunScratchUnlessKept(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	cfg := config.Config{ScratchDir: "/scratch", UniqueExternalExecutionID: "run1", KeepScratch: true}
+	writeTestFile(t, fs, filepath.Join(RunScratchDir(cfg), "step", "main.tf"))
+
+	// act
+	CleanupScratch(fs, logger, cfg)
+
+	// assert
+	_, err := fs.Stat(RunScratchDir(cfg))
+	require.NoError(t, err, "the scratch area should be kept")
+
+	cfg.KeepScratch = false
+	CleanupScratch(fs, logger, cfg)
+
+	_, err = fs.Stat(RunScratchDir(cfg))
+	require.True(t, os.IsNotExist(err), "the scratch area should be removed")
+}
@@ -0,0 +1,204 @@
+package tracks
+
+import (
+	"fmt"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/steps"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// PrepareStepCommand gathers all tracks and prepares a single execution of a step as a deployment would, without
+// deploying anything
+func (tracker DirectoryBasedTracker) PrepareStepCommand(cfg config.Config, address config.StepAddress) (config.StepExecution, config.StepCommand, error) {
+	// every step is gathered, since the execution depends on the outputs of the steps preceding it
+	cfg.TargetAll = true
+	cfg.StepWhitelist = []string{}
+
+	return PrepareStepCommand(tracker.Log, tracker.Fs, cfg, tracker.GatherTracks(cfg), address)
+}
+
+// PrepareStepCommand prepares a single execution of a step as a deployment would, without deploying anything. The
+// outputs of the steps the execution depends on, i.e. the pretrack, the track's primary steps for a regional execution
+// and the track's earlier step progressions, are read from their last deployments.
+func PrepareStepCommand(logger *logrus.Entry, fs afero.Fs, cfg config.Config, tracks []Track, address config.StepAddress) (config.StepExecution, config.StepCommand, error) {
+	preTrack, track, step, regionDeployType, region, err := findStepExecution(cfg, tracks, address)
+
+	if err != nil {
+		return config.StepExecution{}, config.StepCommand{}, err
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"track":            track.Name,
+		"action":           "exec",
+		"region":           region,
+		"regionDeployType": regionDeployType.String(),
+	})
+
+	outputs := map[string]map[string]string{}
+
+	if preTrack != nil && !track.IsPreTrack {
+		preTrackOutputs, err := readTrackOutputs(logger, fs, cfg, *preTrack, regionDeployType, region, preTrack.StepProgressionsCount+1)
+
+		if err != nil {
+			return config.StepExecution{}, config.StepCommand{}, err
+		}
+
+		outputs = AppendPreTrackOutputsToDefaultStepOutputVariables(outputs, &Output{
+			Executions: []RegionExecution{
+				{
+					RegionDeployType: regionDeployType,
+					Region:           region,
+					Output:           ExecutionOutput{StepOutputVariables: preTrackOutputs},
+				},
+			},
+		}, regionDeployType, region)
+	}
+
+	trackOutputs, err := readTrackOutputs(logger, fs, cfg, *track, regionDeployType, region, step.ProgressionLevel)
+
+	if err != nil {
+		return config.StepExecution{}, config.StepCommand{}, err
+	}
+
+	for k, v := range trackOutputs {
+		outputs[k] = v
+	}
+
+	exec, err := prepareStepExecution(logger, fs, step, regionDeployType, region, outputs)
+
+	if err != nil {
+		return exec, config.StepCommand{}, err
+	}
+
+	commander, ok := step.Runner.(config.StepCommander)
+
+	if !ok {
+		return exec, config.StepCommand{}, fmt.Errorf("the %s runner does not support running commands within a step", cfg.Runner)
+	}
+
+	command, err := commander.PrepareStepCommand(exec)
+
+	return exec, command, err
+}
+
+// PrepareStepExecution gathers all tracks and prepares a single execution of a step without deploying anything or
+// reading the outputs of other steps, e.g. to manage the execution's state
+func (tracker DirectoryBasedTracker) PrepareStepExecution(cfg config.Config, address config.StepAddress) (config.Step, config.StepExecution, error) {
+	cfg.TargetAll = true
+	cfg.StepWhitelist = []string{}
+
+	_, track, step, regionDeployType, region, err := findStepExecution(cfg, tracker.GatherTracks(cfg), address)
+
+	if err != nil {
+		return step, config.StepExecution{}, err
+	}
+
+	logger := tracker.Log.WithFields(logrus.Fields{
+		"track":            track.Name,
+		"region":           region,
+		"regionDeployType": regionDeployType.String(),
+	})
+
+	exec, err := prepareStepExecution(logger, tracker.Fs, step, regionDeployType, region, map[string]map[string]string{})
+
+	return step, exec, err
+}
+
+// findStepExecution returns the step of the address, the track containing it and the pretrack, if any, along with the
+// region deploy type and region of the addressed execution
+func findStepExecution(cfg config.Config, tracks []Track, address config.StepAddress) (preTrack *Track, track *Track, step config.Step, regionDeployType config.RegionDeployType, region string, err error) {
+	regionDeployType = config.PrimaryRegionDeployType
+	region = cfg.PrimaryRegion
+
+	if address.Region != "" {
+		regionDeployType = config.RegionalRegionDeployType
+		region = address.Region
+	}
+
+	for i, t := range tracks {
+		if t.IsPreTrack {
+			preTrack = &tracks[i]
+		}
+
+		for _, progressionSteps := range t.OrderedSteps {
+			for _, s := range progressionSteps {
+				if contains([]string{address.StepID}, s.ID) {
+					track = &tracks[i]
+					step = s
+				}
+			}
+		}
+	}
+
+	if track == nil {
+		err = fmt.Errorf("step %s not found", address.StepID)
+	} else if regionDeployType == config.RegionalRegionDeployType && !step.RegionalResourcesExist {
+		err = fmt.Errorf("step %s has no regional resources", step.ID)
+	}
+
+	return
+}
+
+// readTrackOutputs reads the output variables of a track's steps from their last deployments, as a deployment of the
+// track would have collected them in the region before reaching the step progression
+func readTrackOutputs(logger *logrus.Entry, fs afero.Fs, cfg config.Config, t Track, regionDeployType config.RegionDeployType, region string, progression int) (map[string]map[string]string, error) {
+	outputs := map[string]map[string]string{}
+
+	// regional executions start with the outputs of the track's primary execution
+	if regionDeployType == config.RegionalRegionDeployType {
+		primaryOutputs, err := readTrackOutputs(logger, fs, cfg, t, config.PrimaryRegionDeployType, cfg.PrimaryRegion, t.StepProgressionsCount+1)
+
+		if err != nil {
+			return outputs, err
+		}
+
+		outputs = primaryOutputs
+	}
+
+	for progressionLevel := 1; progressionLevel < progression; progressionLevel++ {
+		for _, s := range t.OrderedSteps[progressionLevel] {
+			if regionDeployType == config.RegionalRegionDeployType && !s.RegionalResourcesExist {
+				continue
+			}
+
+			exec, err := prepareStepExecution(logger, fs, s, regionDeployType, region, outputs)
+
+			if err != nil {
+				return outputs, err
+			}
+
+			commander, ok := s.Runner.(config.StepCommander)
+
+			if !ok {
+				return outputs, fmt.Errorf("the %s runner does not support reading the outputs of step %s", cfg.Runner, s.ID)
+			}
+
+			output := commander.ReadStepOutputs(exec)
+
+			if output.Err != nil {
+				return outputs, fmt.Errorf("unable to read the outputs of step %s: %w", s.ID, output.Err)
+			}
+
+			outputs = AppendTrackOutput(outputs, output)
+		}
+	}
+
+	return outputs, nil
+}
+
+// prepareStepExecution initializes and pre-executes a step execution as ExecuteStepImpl would
+func prepareStepExecution(logger *logrus.Entry, fs afero.Fs, s config.Step, regionDeployType config.RegionDeployType, region string, defaultStepOutputVariables map[string]map[string]string) (config.StepExecution, error) {
+	exec, err := steps.InitExecution(s, logger, fs, regionDeployType, region, defaultStepOutputVariables)
+
+	if err != nil {
+		return exec, err
+	}
+
+	if s.Runner == nil {
+		return exec, fmt.Errorf("no runner configured for step %s", s.ID)
+	}
+
+	return s.Runner.PreExecute(exec)
+}
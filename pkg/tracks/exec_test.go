@@ -0,0 +1,113 @@
+package tracks_test
+
+import (
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/tracks"
+	"github.com/stretchr/testify/require"
+)
+
+// commanderStub reads each step's outputs as its name and records the executions it prepared
+type commanderStub struct {
+	read     *[]string
+	prepared *[]config.StepExecution
+}
+
+func (c commanderStub) PreExecute(exec config.StepExecution) (config.StepExecution, error) {
+	return exec, nil
+}
+
+func (c commanderStub) ExecuteStep(exec config.StepExecution) config.StepOutput {
+	panic("exec should not deploy steps")
+}
+
+func (c commanderStub) ExecuteStepTests(exec config.StepExecution) config.StepTestOutput {
+	panic("exec should not test steps")
+}
+
+func (c commanderStub) ExecuteStepDestroy(exec config.StepExecution) config.StepOutput {
+	panic("exec should not destroy steps")
+}
+
+func (c commanderStub) PrepareStepCommand(exec config.StepExecution) (config.StepCommand, error) {
+	*c.prepared = append(*c.prepared, exec)
+	return config.StepCommand{Binary: "terraform", Dir: exec.Dir}, nil
+}
+
+func (c commanderStub) ReadStepOutputs(exec config.StepExecution) config.StepOutput {
+	*c.read = append(*c.read, exec.StepID)
+	return config.StepOutput{
+		StepName:         exec.StepName,
+		RegionDeployType: exec.RegionDeployType,
+		Region:           exec.Region,
+		OutputVariables:  map[string]interface{}{"name": exec.StepName},
+	}
+}
+
+func TestPrepareStepCommand_ShouldReadOutputsOfPrecedingSteps(t *testing.T) {
+	read := []string{}
+	prepared := []config.StepExecution{}
+	runner := commanderStub{read: &read, prepared: &prepared}
+
+	cfg := config.Config{PrimaryRegion: "us-east-1", Runner: "terraform"}
+
+	stubStep := func(track string, name string, progression int) config.Step {
+		return config.Step{
+			ID:               track + "/" + name,
+			Name:             name,
+			TrackName:        track,
+			Dir:              "tracks/" + track + "/step" + name,
+			ProgressionLevel: progression,
+			DeployConfig:     cfg,
+			Runner:           runner,
+		}
+	}
+
+	stubTracks := []tracks.Track{
+		{
+			Name:                  tracks.PRE_TRACK_NAME,
+			IsPreTrack:            true,
+			StepProgressionsCount: 1,
+			OrderedSteps:          map[int][]config.Step{1: {stubStep(tracks.PRE_TRACK_NAME, "identity", 1)}},
+		},
+		{
+			Name:                  "core",
+			StepProgressionsCount: 3,
+			OrderedSteps: map[int][]config.Step{
+				1: {stubStep("core", "network", 1)},
+				2: {stubStep("core", "dns", 2), stubStep("core", "sibling", 2)},
+				3: {stubStep("core", "app", 3)},
+			},
+		},
+	}
+
+	exec, command, err := tracks.PrepareStepCommand(logger, fs, cfg, stubTracks, config.StepAddress{StepID: "core/dns"})
+
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"_pretrack/identity", "core/network"}, read, "Should only read the outputs of the pretrack and earlier progressions")
+	require.Len(t, prepared, 1)
+	require.Equal(t, "core/dns", exec.StepID)
+	require.Equal(t, config.PrimaryRegionDeployType, exec.RegionDeployType)
+	require.Equal(t, "us-east-1", exec.Region)
+	require.Equal(t, "terraform", command.Binary)
+	require.Equal(t, "network", exec.OptionalStepParams["network-name"])
+	require.Equal(t, "identity", exec.OptionalStepParams["pretrack-identity-name"])
+}
+
+func TestPrepareStepCommand_ShouldFailForUnknownStepsAndRegions(t *testing.T) {
+	cfg := config.Config{PrimaryRegion: "us-east-1"}
+	stubTracks := []tracks.Track{
+		{
+			Name:                  "core",
+			StepProgressionsCount: 1,
+			OrderedSteps:          map[int][]config.Step{1: {{ID: "core/network", Name: "network", ProgressionLevel: 1}}},
+		},
+	}
+
+	_, _, err := tracks.PrepareStepCommand(logger, fs, cfg, stubTracks, config.StepAddress{StepID: "core/unknown"})
+	require.Error(t, err)
+
+	_, _, err = tracks.PrepareStepCommand(logger, fs, cfg, stubTracks, config.StepAddress{StepID: "core/network", Region: "us-west-2"})
+	require.EqualError(t, err, "step core/network has no regional resources")
+}
@@ -0,0 +1,174 @@
+package tracks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/steps"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// maxPreflightChecks is the number of directories checked at once, bounding the concurrent runner initializations
+var maxPreflightChecks = runtime.NumCPU()
+
+// PreflightCheck gathers all tracks and checks every step before any step is deployed
+func (tracker DirectoryBasedTracker) PreflightCheck(cfg config.Config) []config.PreflightIssue {
+	return PreflightCheck(tracker.Log, tracker.Fs, cfg, tracker.GatherTracks(cfg))
+}
+
+// PreflightCheck checks the primary and regional directory of every step whose runner implements
+// config.PreflightChecker. Regional directories are checked once, in the first regional region, rather than per region.
+// Every directory is checked in an isolated working directory, so checks never modify the source tree, e.g. lock files
+// written by terraform init. The directories are checked concurrently, at most maxPreflightChecks at once, and the issues
+// are returned in step order.
+func PreflightCheck(logger *logrus.Entry, fs afero.Fs, cfg config.Config, tracks []Track) []config.PreflightIssue {
+	regionalRegion := cfg.PrimaryRegion
+	if len(cfg.RegionalRegions) > 0 {
+		regionalRegion = cfg.RegionalRegions[0]
+	}
+
+	executions := []config.StepExecution{}
+	checkers := []config.PreflightChecker{}
+
+	for _, t := range tracks {
+		for progression := 1; progression <= t.StepProgressionsCount; progression++ {
+			for _, s := range t.OrderedSteps[progression] {
+				checker, ok := s.Runner.(config.PreflightChecker)
+				if !ok {
+					continue
+				}
+
+				stepLogger := logger.WithFields(logrus.Fields{
+					"track":  t.Name,
+					"action": "preflight",
+				})
+
+				executions = append(executions, steps.NewExecution(s, stepLogger, fs, config.PrimaryRegionDeployType, cfg.PrimaryRegion, map[string]map[string]string{}))
+				checkers = append(checkers, checker)
+
+				if s.RegionalResourcesExist {
+					exec := steps.NewExecution(s, stepLogger, fs, config.RegionalRegionDeployType, regionalRegion, map[string]map[string]string{})
+					exec.Dir = filepath.Join(s.Dir, "regional")
+					exec.SourceDir = exec.Dir
+
+					executions = append(executions, exec)
+					checkers = append(checkers, checker)
+				}
+			}
+		}
+	}
+
+	results := make([][]config.PreflightIssue, len(executions))
+
+	scratch, err := preflightScratch(fs, cfg)
+	if err != nil {
+		return []config.PreflightIssue{{Check: "workdir", Message: err.Error()}}
+	}
+
+	if cfg.KeepScratch {
+		logger.Infof("Keeping the working directories of the pre-flight checks in %s", scratch.ScratchDir)
+	} else {
+		defer fs.RemoveAll(scratch.ScratchDir)
+	}
+
+	var wg sync.WaitGroup
+
+	limit := maxPreflightChecks
+	if limit < 1 {
+		limit = 1
+	}
+
+	sem := make(chan struct{}, limit)
+
+	for i := range executions {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = checkStep(scratch, checkers[i], executions[i])
+		}(i)
+	}
+
+	wg.Wait()
+
+	issues := []config.PreflightIssue{}
+	for _, result := range results {
+		issues = append(issues, result...)
+	}
+
+	return issues
+}
+
+// preflightScratch returns the configuration of the scratch area the pre-flight checks' working directories are created
+// in, a new directory within the configured scratch directory
+func preflightScratch(fs afero.Fs, cfg config.Config) (config.Config, error) {
+	root := cfg.ScratchDir
+	if root == "" {
+		root = os.TempDir()
+	}
+
+	if err := fs.MkdirAll(root, 0700); err != nil {
+		return cfg, err
+	}
+
+	dir, err := afero.TempDir(fs, root, "preflight-")
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.ScratchDir = dir
+
+	return cfg, nil
+}
+
+// checkStep checks the execution's source directory in a working directory created for the check. Issues report the
+// source directory rather than the working directory.
+func checkStep(scratch config.Config, checker config.PreflightChecker, exec config.StepExecution) []config.PreflightIssue {
+	dir, err := steps.CreateWorkingDir(exec.Fs, scratch, exec, exec.SourceDir)
+	if err != nil {
+		return []config.PreflightIssue{{
+			StepID:           exec.StepID,
+			RegionDeployType: exec.RegionDeployType,
+			Dir:              exec.SourceDir,
+			Check:            "workdir",
+			Message:          err.Error(),
+		}}
+	}
+
+	exec.Dir = dir
+
+	issues := checker.CheckStep(exec)
+	for i := range issues {
+		issues[i].Dir = exec.SourceDir
+	}
+
+	return issues
+}
+
+// PreflightReport consolidates the issues of the pre-flight checks into a single report, with the messages indented
+// below each issue
+func PreflightReport(issues []config.PreflightIssue) string {
+	report := []string{}
+
+	for _, issue := range issues {
+		report = append(report, fmt.Sprintf("%s/%s (%s) failed %s:", issue.StepID, issue.RegionDeployType, issue.Dir, issue.Check))
+
+		for _, line := range strings.Split(strings.TrimSpace(issue.Message), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				report = append(report, "    "+line)
+			}
+		}
+	}
+
+	return strings.Join(report, "\n")
+}
@@ -0,0 +1,98 @@
+package tracks_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/tracks"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// checkerStub reports an issue for every directory it checks, writing a lock file into the directory as terraform init does
+type checkerStub struct {
+	commanderStub
+}
+
+func (c checkerStub) CheckStep(exec config.StepExecution) []config.PreflightIssue {
+	_ = afero.WriteFile(exec.Fs, filepath.Join(exec.Dir, ".terraform.lock.hcl"), []byte{}, 0644)
+
+	return []config.PreflightIssue{{
+		StepID:           exec.StepID,
+		RegionDeployType: exec.RegionDeployType,
+		Dir:              exec.Dir,
+		Check:            "validate",
+		Message:          exec.Region + "\n\n  Error: invalid\n",
+	}}
+}
+
+func TestPreflightCheck_ShouldCheckPrimaryAndRegionalDirectories(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/project"
+
+	networkDir := filepath.Join(root, "tracks/core/step1_network")
+	dnsDir := filepath.Join(root, "tracks/core/step2_dns")
+
+	require.NoError(t, fs.MkdirAll(filepath.Join(networkDir, "regional"), 0755))
+	require.NoError(t, fs.MkdirAll(dnsDir, 0755))
+
+	cfg := config.Config{PrimaryRegion: "us-east-1", RegionalRegions: []string{"us-east-2", "us-east-1"}, Runner: "terraform", ScratchDir: filepath.Join(root, "scratch")}
+
+	stubTracks := []tracks.Track{
+		{
+			Name:                  "core",
+			StepProgressionsCount: 2,
+			OrderedSteps: map[int][]config.Step{
+				1: {{ID: "core/network", Name: "network", TrackName: "core", Dir: networkDir, ProgressionLevel: 1, RegionalResourcesExist: true, DeployConfig: cfg, Runner: checkerStub{}}},
+				2: {{ID: "core/dns", Name: "dns", TrackName: "core", Dir: dnsDir, ProgressionLevel: 2, DeployConfig: cfg, Runner: checkerStub{}}},
+			},
+		},
+	}
+
+	issues := tracks.PreflightCheck(logrus.NewEntry(logrus.New()), fs, cfg, stubTracks)
+
+	require.Len(t, issues, 3)
+	require.Equal(t, networkDir, issues[0].Dir)
+	require.Equal(t, config.PrimaryRegionDeployType, issues[0].RegionDeployType)
+	require.Equal(t, filepath.Join(networkDir, "regional"), issues[1].Dir)
+	require.Equal(t, config.RegionalRegionDeployType, issues[1].RegionDeployType)
+	require.True(t, strings.HasPrefix(issues[1].Message, "us-east-2"))
+	require.Equal(t, dnsDir, issues[2].Dir)
+
+	for _, dir := range []string{networkDir, filepath.Join(networkDir, "regional"), dnsDir} {
+		_, err := fs.Stat(filepath.Join(dir, ".terraform.lock.hcl"))
+		require.True(t, os.IsNotExist(err), "checks should not modify the source directory %s", dir)
+	}
+
+	scratch, err := afero.ReadDir(fs, cfg.ScratchDir)
+	require.NoError(t, err)
+	require.Empty(t, scratch, "the working directories of the checks should be removed")
+
+	require.Equal(t, strings.Join([]string{
+		"core/network/primary (" + networkDir + ") failed validate:",
+		"    us-east-1",
+		"    Error: invalid",
+	}, "\n"), tracks.PreflightReport(issues[:1]))
+}
+
+func TestPreflightCheck_ShouldSkipRunnersWithoutChecks(t *testing.T) {
+	cfg := config.Config{PrimaryRegion: "us-east-1", Runner: "terraform"}
+
+	stubTracks := []tracks.Track{
+		{
+			Name:                  "core",
+			StepProgressionsCount: 1,
+			OrderedSteps: map[int][]config.Step{
+				1: {{ID: "core/network", Name: "network", TrackName: "core", Dir: "tracks/core/step1_network", ProgressionLevel: 1, DeployConfig: cfg, Runner: commanderStub{}}},
+			},
+		},
+	}
+
+	issues := tracks.PreflightCheck(logrus.NewEntry(logrus.New()), afero.NewMemMapFs(), cfg, stubTracks)
+
+	require.Empty(t, issues)
+}
@@ -5,14 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/optum/runiac/pkg/cloudaccountdeployment"
 	"github.com/optum/runiac/pkg/config"
 	"github.com/optum/runiac/pkg/steps"
-	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
-	"github.com/otiai10/copy"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 )
@@ -43,12 +42,16 @@ var ExecuteStep ExecuteStepFunc = ExecuteStepImpl
 type Tracker interface {
 	GatherTracks(config config.Config) (tracks []Track)
 	ExecuteTracks(config config.Config) (output Stage)
+	PrepareStepCommand(config config.Config, address config.StepAddress) (config.StepExecution, config.StepCommand, error)
+	PrepareStepExecution(config config.Config, address config.StepAddress) (config.Step, config.StepExecution, error)
+	PreflightCheck(config config.Config) []config.PreflightIssue
 }
 
 // DirectoryBasedTracker implements the Tracker interface
 type DirectoryBasedTracker struct {
-	Log *logrus.Entry
-	Fs  afero.Fs
+	Log    *logrus.Entry
+	Fs     afero.Fs
+	Plugin config.RunnerPlugin // Runner plugin, prepared once with all gathered steps when it implements config.RunPreparer
 }
 
 // Track represents a delivery framework track (unit of functionality)
@@ -99,20 +102,22 @@ type RegionExecution struct {
 
 // TrackOutput represents the output from a track execution
 type ExecutionOutput struct {
-	Name                string
-	Dir                 string
-	ExecutedCount       int
-	SkippedCount        int
-	FailureCount        int
-	FailedTestCount     int
-	Steps               map[string]config.Step
-	FailedSteps         []config.Step
-	StepOutputVariables map[string]map[string]string // Output variables across all steps in the track. A map where K={step name} and V={map[outputVarName: outputVarVal]}
+	Name                         string
+	Dir                          string
+	ExecutedCount                int
+	SkippedCount                 int
+	FailureCount                 int
+	FailedTestCount              int
+	Steps                        map[string]config.Step
+	FailedSteps                  []config.Step
+	StepOutputVariables          map[string]map[string]string // Output variables across all steps in the track. A map where K={step name} and V={map[outputVarName: outputVarVal]}
+	SensitiveStepOutputVariables map[string]map[string]bool   // Output variables flagged as sensitive, keyed the same as StepOutputVariables
 }
 
 // Stage represents the outputs of tracks
 type Stage struct {
-	Tracks map[string]Track
+	Tracks          map[string]Track
+	PreflightIssues []config.PreflightIssue // Failed pre-flight checks, no track is executed when any check failed
 }
 
 // GatherTracks gets all tracks that should be executed based
@@ -133,6 +138,12 @@ func (tracker DirectoryBasedTracker) GatherTracks(config config.Config) (tracks
 	// read tracks from the usual tracks directory
 	items, _ := afero.ReadDir(tracker.Fs, tracksDir)
 	for _, item := range items {
+		// earlier versions of runiac copied the default track's steps into tracks/default, which is stale once a default track exists
+		if item.IsDir() && item.Name() == DEFAULT_TRACK_NAME && defaultExists {
+			tracker.Log.Warnf("Skipping %s/%s, the default track is read from %s. It may have been generated by an earlier version of runiac and can be removed.", tracksDir, item.Name(), defaultDir)
+			continue
+		}
+
 		if item.IsDir() {
 			t, included, _ := tracker.readTrack(config, item.Name(), fmt.Sprintf("%s/%s", tracksDir, item.Name()))
 			if included && t.StepsCount > 0 {
@@ -150,27 +161,21 @@ func (tracker DirectoryBasedTracker) GatherTracks(config config.Config) (tracks
 	return
 }
 
-func copyDefault(source, destination string) error {
-	var err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+// defaultTrackStepFolder matches the step folder convention, step{progressionLevel}_{stepName}
+var defaultTrackStepFolder = regexp.MustCompile(`^step[0-9]_.+$`)
 
-		if strings.HasPrefix(path, "tracks") {
-			return nil
-		}
-
-		var relPath = strings.Replace(path, source, "", 1)
-		if relPath == "" {
-			return nil
-		}
+// isDefaultTrackStep returns whether a folder of the project's top-level directory is a step of the default track. The
+// runner plugin decides whether the folder contains its step configuration, when it is able to.
+func (tracker DirectoryBasedTracker) isDefaultTrackStep(dir string) bool {
+	if !defaultTrackStepFolder.MatchString(filepath.Base(dir)) {
+		return false
+	}
 
-		if strings.HasPrefix(relPath, "step") {
-			fmt.Println(relPath)
+	if detector, ok := tracker.Plugin.(config.StepDetector); ok {
+		return detector.IsStepDir(tracker.Fs, dir)
+	}
 
-			return copy.Copy(path, filepath.Join(destination, relPath))
-		} else {
-			return nil
-		}
-	})
-	return err
+	return true
 }
 
 func (tracker DirectoryBasedTracker) readTrack(cfg config.Config, name string, dir string) (Track, bool, error) {
@@ -188,18 +193,6 @@ func (tracker DirectoryBasedTracker) readTrack(cfg config.Config, name string, d
 		t.IsDefaultTrack = true
 	}
 
-	if t.IsDefaultTrack {
-		matches, _ := afero.Glob(tracker.Fs, "*.tf") // TODO(plugin): shift this check to a plugin to support more than terraform
-		if len(matches) > 0 {
-			_ = tracker.Fs.MkdirAll("./tracks/default/", 0755)
-			err := copyDefault("./", "./tracks/default/")
-			if err != nil {
-				tracker.Log.WithError(err).Error("Failed to set up default track step")
-				return t, false, err
-			}
-		}
-	}
-
 	// TODO(step:config)
 	//tConfig := viper.New()
 	//tConfig.SetConfigName("runiac")         // name of cfg file (without extension)
@@ -231,6 +224,12 @@ func (tracker DirectoryBasedTracker) readTrack(cfg config.Config, name string, d
 		for _, tFolder := range tFolders {
 			tFolderName := tFolder.Name()
 
+			// the default track's steps are read in place from the project's top-level directory, which may contain other
+			// folders, so only folders the runner recognizes as steps are included
+			if t.IsDefaultTrack && !tracker.isDefaultTrackStep(filepath.Join(t.Dir, tFolderName)) {
+				continue
+			}
+
 			// step folder convention is step{progressionLevel}_{stepName}
 			if strings.HasPrefix(tFolderName, stepPrefix) {
 				stepName := tFolderName[len(stepPrefix)+2:]
@@ -269,6 +268,12 @@ func (tracker DirectoryBasedTracker) readTrack(cfg config.Config, name string, d
 					ID:               stepID,
 				}
 
+				step.Config, err = config.GetStepConfig(tracker.Fs, step.Dir)
+
+				if err != nil {
+					tracker.Log.WithError(err).Errorf("Error reading step %s configuration file", stepID)
+				}
+
 				step.TestsExist = fileExists(tracker.Fs, filepath.Join(step.Dir, "tests/tests.test"))
 				step.RegionalResourcesExist = exists(tracker.Fs, filepath.Join(step.Dir, "regional"))
 				step.Runner = steps.DetermineRunner(step)
@@ -324,9 +329,37 @@ func exists(fs afero.Fs, filename string) bool {
 // all other tracks.
 func (tracker DirectoryBasedTracker) ExecuteTracks(cfg config.Config) (output Stage) {
 	output.Tracks = map[string]Track{}
+
+	defer steps.CleanupScratch(tracker.Fs, tracker.Log, cfg)
+
 	var tracks = tracker.GatherTracks(cfg) // **All** tracks
 	var parallelTracks []Track             // Tracks that should be executed in parallel
 
+	if preparer, ok := tracker.Plugin.(config.RunPreparer); ok {
+		var steps []config.Step
+		for _, t := range tracks {
+			for _, progressionSteps := range t.OrderedSteps {
+				steps = append(steps, progressionSteps...)
+			}
+		}
+		preparer.PrepareRun(tracker.Log, steps)
+	}
+
+	if cfg.Preflight {
+		output.PreflightIssues = PreflightCheck(tracker.Log, tracker.Fs, cfg, tracks)
+
+		if len(output.PreflightIssues) > 0 {
+			tracker.Log.Errorf("Pre-flight checks failed, no tracks will be executed:\n%s", PreflightReport(output.PreflightIssues))
+
+			for _, t := range tracks {
+				t.Skipped = true
+				output.Tracks[t.Name] = t
+			}
+
+			return
+		}
+	}
+
 	// Pre track
 	var preTrackExists bool
 	var preTrack Track
@@ -418,13 +451,28 @@ func (tracker DirectoryBasedTracker) ExecuteTracks(cfg config.Config) (output St
 
 		for _, t := range parallelTracks {
 			executionStepOutputVariables := map[string]map[string]map[string]string{}
+			sensitiveStepOutputVariables := map[string]map[string]bool{}
 
 			for _, exec := range output.Tracks[t.Name].Output.Executions {
 				executionStepOutputVariables[fmt.Sprintf("%s-%s", exec.RegionDeployType, exec.Region)] = exec.Output.StepOutputVariables
+
+				for step, vars := range exec.Output.SensitiveStepOutputVariables {
+					if sensitiveStepOutputVariables[step] == nil {
+						sensitiveStepOutputVariables[step] = map[string]bool{}
+					}
+					for k, v := range vars {
+						sensitiveStepOutputVariables[step][k] = v
+					}
+				}
 			}
 
 			if tracker.Log.Level == logrus.DebugLevel {
-				jsonBytes, _ := json.Marshal(executionStepOutputVariables)
+				maskedExecutionStepOutputVariables := map[string]map[string]map[string]string{}
+				for k, v := range executionStepOutputVariables {
+					maskedExecutionStepOutputVariables[k] = MaskStepOutputVariables(v, sensitiveStepOutputVariables)
+				}
+
+				jsonBytes, _ := json.Marshal(maskedExecutionStepOutputVariables)
 
 				tracker.Log.Debugf("OUTPUT VARS: %s", string(jsonBytes))
 			}
@@ -503,12 +551,57 @@ func AppendTrackOutput(trackOutputVariables map[string]map[string]string, output
 	}
 
 	for k, v := range output.OutputVariables {
-		trackOutputVariables[key][k] = terraform.OutputToString(v)
+		trackOutputVariables[key][k] = config.OutputToString(v)
 	}
 
 	return trackOutputVariables
 }
 
+// Adds the names of a step's sensitive output variables to the track's sensitive output variables map.
+// Keys match AppendTrackOutput so the maps can be used together
+func AppendTrackSensitiveOutput(trackSensitiveOutputVariables map[string]map[string]bool, output config.StepOutput) map[string]map[string]bool {
+	if len(output.SensitiveOutputVariables) == 0 {
+		return trackSensitiveOutputVariables
+	}
+
+	key := output.StepName
+
+	if output.RegionDeployType == config.RegionalRegionDeployType {
+		key = fmt.Sprintf("%s-%s", key, output.RegionDeployType.String())
+	}
+
+	if trackSensitiveOutputVariables[key] == nil {
+		trackSensitiveOutputVariables[key] = make(map[string]bool)
+	}
+
+	for k, v := range output.SensitiveOutputVariables {
+		if v {
+			trackSensitiveOutputVariables[key][k] = true
+		}
+	}
+
+	return trackSensitiveOutputVariables
+}
+
+// MaskStepOutputVariables returns a copy of the step output variables with sensitive values replaced,
+// suitable for logging or reporting
+func MaskStepOutputVariables(stepOutputVariables map[string]map[string]string, sensitiveStepOutputVariables map[string]map[string]bool) map[string]map[string]string {
+	masked := map[string]map[string]string{}
+
+	for step, vars := range stepOutputVariables {
+		masked[step] = map[string]string{}
+		for k, v := range vars {
+			if sensitiveStepOutputVariables[step][k] {
+				masked[step][k] = config.MaskedValue
+			} else {
+				masked[step][k] = v
+			}
+		}
+	}
+
+	return masked
+}
+
 func AppendPreTrackOutputsToDefaultStepOutputVariables(defaultStepOutputVariables map[string]map[string]string, preTrackOutput *Output, regionDeployType config.RegionDeployType, region string) map[string]map[string]string {
 	for _, execution := range preTrackOutput.Executions {
 		if execution.RegionDeployType == regionDeployType && execution.Region == region {
@@ -766,6 +859,8 @@ func ExecuteDeployTrackRegion(in <-chan RegionExecution, out chan<- RegionExecut
 		execution.Output.StepOutputVariables = map[string]map[string]string{}
 	}
 
+	execution.Output.SensitiveStepOutputVariables = map[string]map[string]bool{}
+
 	// define test channel outside of stepProgression loop to allow tests to run in background while steps proceed through progressions
 	testOutChan := make(chan config.StepTestOutput)
 	testInChan := make(chan config.Step)
@@ -823,6 +918,7 @@ func ExecuteDeployTrackRegion(in <-chan RegionExecution, out chan<- RegionExecut
 			}
 			execution.Output.Steps[s.Name] = s
 			execution.Output.StepOutputVariables = AppendTrackOutput(execution.Output.StepOutputVariables, s.Output)
+			execution.Output.SensitiveStepOutputVariables = AppendTrackSensitiveOutput(execution.Output.SensitiveStepOutputVariables, s.Output)
 
 			if s.Output.Err != nil || s.Output.Status == config.Fail {
 				execution.Output.FailureCount++
@@ -938,7 +1034,23 @@ func ExecuteStepImpl(region string, regionDeployType config.RegionDeployType,
 
 	var output config.StepOutput
 
-	exec2, _ := s.Runner.PreExecute(exec)
+	exec2, err := s.Runner.PreExecute(exec)
+
+	// if error preparing the execution, short circuit before the runner executes
+	if err != nil {
+		exec.Logger.WithError(err).Error("Error preparing step execution")
+		s.Output = config.StepOutput{
+			Status:           config.Fail,
+			RegionDeployType: regionDeployType,
+			Region:           region,
+			StepName:         s.Name,
+			StreamOutput:     "",
+			Err:              err,
+			OutputVariables:  nil,
+		}
+		out <- s
+		return
+	}
 
 	if destroy {
 		output = steps.ExecuteStepDestroy(s.Runner, exec2)
@@ -6,12 +6,14 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/optum/runiac/pkg/config"
 	"github.com/optum/runiac/pkg/tracks"
+	pluginsterraform "github.com/optum/runiac/plugins/terraform"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 )
 
@@ -536,6 +538,20 @@ func TestAddToTrackOutput(t *testing.T) {
 	require.Equal(t, "resource/my-cool-resource", mockPrevStepVars[stepOutput.StepName]["resource_id"], "The track output should have the correct key and value set")
 }
 
+func TestAddToTrackOutput_ShouldHandleSecureOutputsWithoutValue(t *testing.T) {
+	stepOutput := config.StepOutput{
+		// ARM does not return the value of secureString and secureObject outputs
+		OutputVariables:          map[string]interface{}{"adminPassword": nil, "vnetId": "/subscriptions/0000/vnet"},
+		SensitiveOutputVariables: map[string]bool{"adminPassword": true},
+		StepName:                 "arm_step1",
+	}
+
+	trackOutputVars := tracks.AppendTrackOutput(make(map[string]map[string]string), stepOutput)
+
+	require.Equal(t, "", trackOutputVars[stepOutput.StepName]["adminPassword"])
+	require.Equal(t, "/subscriptions/0000/vnet", trackOutputVars[stepOutput.StepName]["vnetId"])
+}
+
 func TestAppendPreTrackOutputsToDefaultStepOutputVariables_AddsPrimaryRegionExecutionsFromPreTrackToVars(t *testing.T) {
 	// Mock existing step output vars
 	defaultStepOutputVariables := make(map[string]map[string]string)
@@ -698,6 +714,31 @@ func TestAppendTrackOutput_WithRegionalStepDeploymentOutput(t *testing.T) {
 	}
 }
 
+func TestMaskStepOutputVariables_ShouldMaskSensitiveOutputs(t *testing.T) {
+	stepOutput := config.StepOutput{
+		OutputVariables: map[string]interface{}{
+			"resource_name": "my-cool-resource",
+			"db_password":   "hunter2",
+		},
+		SensitiveOutputVariables: map[string]bool{
+			"db_password": true,
+		},
+		StepName:         "cool_step1",
+		RegionDeployType: config.RegionalRegionDeployType,
+	}
+
+	trackOutputVars := tracks.AppendTrackOutput(map[string]map[string]string{}, stepOutput)
+	trackSensitiveVars := tracks.AppendTrackSensitiveOutput(map[string]map[string]bool{}, stepOutput)
+
+	key := fmt.Sprintf("%s-%s", stepOutput.StepName, config.RegionalRegionDeployType.String())
+
+	masked := tracks.MaskStepOutputVariables(trackOutputVars, trackSensitiveVars)
+
+	require.Equal(t, "my-cool-resource", masked[key]["resource_name"], "Non-sensitive outputs should not be masked")
+	require.Equal(t, config.MaskedValue, masked[key]["db_password"], "Sensitive outputs should be masked")
+	require.Equal(t, "hunter2", trackOutputVars[key]["db_password"], "Masking should not modify the values passed to downstream steps")
+}
+
 type spyExecuteStep struct {
 	OutputVars map[string]map[string]string
 	StepName   string
@@ -922,3 +963,83 @@ func TestExecuteDeployTrackRegion_ShouldNaWhenRegionalResourcesDoNotExist(t *tes
 	require.NotNil(t, primaryTrackExecution)
 	require.Equal(t, config.Na, primaryTrackExecution.Output.Steps["step_p1"].Output.Status)
 }
+
+type preparerSpy struct {
+	steps *[]config.Step
+}
+
+func (p preparerSpy) Initialize(logger *logrus.Entry) {}
+
+func (p preparerSpy) PrepareRun(logger *logrus.Entry, steps []config.Step) {
+	*p.steps = append(*p.steps, steps...)
+}
+
+func TestExecuteTracks_ShouldPreparePluginWithAllSteps(t *testing.T) {
+	originalDeployTrack := tracks.DeployTrack
+	defer func() { tracks.DeployTrack = originalDeployTrack }()
+
+	tracks.DeployTrack = func(execution tracks.Execution, cfg config.Config, t tracks.Track, out chan<- tracks.Output) {
+		out <- tracks.Output{Name: t.Name}
+	}
+
+	prepared := []config.Step{}
+
+	tracker := tracks.DirectoryBasedTracker{
+		Fs:     fs,
+		Log:    logger,
+		Plugin: preparerSpy{steps: &prepared},
+	}
+
+	_ = tracker.ExecuteTracks(config.Config{TargetAll: true})
+
+	stepCount := 0
+	for _, tr := range tracker.GatherTracks(config.Config{TargetAll: true}) {
+		stepCount += tr.StepsCount
+	}
+
+	require.NotZero(t, stepCount)
+	require.Len(t, prepared, stepCount, "Should prepare the plugin once with every gathered step")
+}
+
+func TestGatherTracks_ShouldReadDefaultTrackInPlace(t *testing.T) {
+	projectFs := afero.NewMemMapFs()
+
+	for _, f := range []string{
+		"step1_network/main.tf",
+		"step2_dns/main.tf",
+		"step3_docs/README.md",
+		"stepfunctions/definition.json",
+		"tracks/default/step1_network/main.tf",
+		"tracks/core/step1_iam/main.tf",
+	} {
+		require.NoError(t, afero.WriteFile(projectFs, f, []byte(""), 0644))
+	}
+
+	tracker := tracks.DirectoryBasedTracker{
+		Fs:     projectFs,
+		Log:    logger,
+		Plugin: pluginsterraform.TerraformPlugin{Fs: projectFs},
+	}
+
+	// act
+	gathered := tracker.GatherTracks(config.Config{TargetAll: true})
+
+	// assert
+	stepIDs := map[string][]string{}
+	for _, tr := range gathered {
+		for _, progressionSteps := range tr.OrderedSteps {
+			for _, s := range progressionSteps {
+				stepIDs[tr.Name] = append(stepIDs[tr.Name], s.ID+"="+s.Dir)
+			}
+		}
+	}
+
+	sort.Strings(stepIDs["default"])
+
+	require.Len(t, gathered, 2, "The stale tracks/default should not be read as another track")
+	require.Equal(t, []string{"default/dns=step2_dns", "default/network=step1_network"}, stepIDs["default"], "Only folders with terraform configuration should be default steps")
+	require.Equal(t, []string{"core/iam=tracks/core/step1_iam"}, stepIDs["core"])
+
+	exists, _ := afero.DirExists(projectFs, "tracks/default/step2_dns")
+	require.False(t, exists, "The project tree should not be modified")
+}
@@ -1,5 +1,7 @@
 package plugins_arm
 
+import "encoding/json"
+
 // Struct representing the final deployment metadata plan stored by ARM
 type deployment struct {
 	Name string `json:"name"`
@@ -9,6 +11,13 @@ type deployment struct {
 // Struct that contains a list of resources created as part of a template
 type deploymentProperties struct {
 	OutputResources []outputResource `json:"outputResources"`
+	Outputs map[string]deploymentOutput `json:"outputs"`
+}
+
+// Struct that contains each output of a template, along with its ARM type
+type deploymentOutput struct {
+	Type string `json:"type"`
+	Value json.RawMessage `json:"value"`
 }
 
 // Struct that contains each resource created by a template
@@ -0,0 +1,105 @@
+package plugins_arm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/arm/pkg/arm"
+)
+
+const (
+	bicepTemplateFile   = "main.bicep"
+	compiledBicepFile   = ".temp/main.bicep.json" // relative to the execution directory
+	defaultTemplateFile = "main.json"
+)
+
+// bicepTargetScope matches the targetScope declaration of a Bicep file, e.g. targetScope = 'subscription'
+var bicepTargetScope = regexp.MustCompile(`(?m)^\s*targetScope\s*=\s*'([A-Za-z]+)'`)
+
+// isBicepStep returns whether the execution's template is written in Bicep, which takes precedence over main.json
+func isBicepStep(exec config.StepExecution) bool {
+	_, err := os.Stat(filepath.Join(exec.Dir, bicepTemplateFile))
+	return err == nil
+}
+
+// mainTemplateSource returns the ARM template of the execution relative to its directory, the compiled Bicep template
+// when the step contains main.bicep
+func mainTemplateSource(exec config.StepExecution) string {
+	if isBicepStep(exec) {
+		return compiledBicepFile
+	}
+
+	return defaultTemplateFile
+}
+
+// buildBicep compiles the step's main.bicep into the execution directory with az bicep build, recording the compiler's
+// diagnostics on the step's output. Error diagnostics are attached to the returned error with their location.
+func buildBicep(exec config.StepExecution, options *arm.Options, output *config.StepOutput) error {
+	if !isBicepStep(exec) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(exec.Dir, ".temp"), 0755); err != nil {
+		return err
+	}
+
+	out, err := azureCLI.BicepBuild(options, bicepTemplateFile, compiledBicepFile)
+
+	errs := []arm.BicepDiagnostic{}
+
+	for _, d := range arm.ParseBicepDiagnostics(out) {
+		if rel, relErr := filepath.Rel(exec.Dir, d.Filename); relErr == nil && !strings.HasPrefix(rel, "..") {
+			d.Filename = rel
+		}
+
+		logger := options.Logger.WithField("file", fmt.Sprintf("%s:%d", d.Filename, d.Line))
+
+		switch d.Severity {
+		case "error":
+			logger.Errorf("%s: %s", d.Code, d.Message)
+			errs = append(errs, d)
+		case "warning":
+			logger.Warnf("%s: %s", d.Code, d.Message)
+		default:
+			logger.Infof("%s: %s", d.Code, d.Message)
+			continue
+		}
+
+		output.Diagnostics = append(output.Diagnostics, config.Diagnostic{
+			Severity: d.Severity,
+			Summary:  fmt.Sprintf("%s: %s", d.Code, d.Message),
+			Filename: d.Filename,
+			Line:     d.Line,
+		})
+	}
+
+	if err != nil {
+		if len(errs) > 0 {
+			return &arm.BicepDiagnosticsError{Err: err, Diagnostics: errs}
+		}
+
+		return fmt.Errorf("unable to build %s: %w: %s", bicepTemplateFile, err, strings.TrimSpace(out))
+	}
+
+	return nil
+}
+
+// readBicepScope returns the deployment scope of a Bicep file's targetScope, which defaults to the resource group
+func readBicepScope(bicepFile string) (arm.Scope, error) {
+	data, err := ioutil.ReadFile(bicepFile)
+	if err != nil {
+		return "", err
+	}
+
+	m := bicepTargetScope.FindSubmatch(data)
+	if m == nil {
+		return arm.ResourceGroupScope, nil
+	}
+
+	return arm.ParseScope(string(m[1]))
+}
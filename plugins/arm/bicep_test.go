@@ -0,0 +1,76 @@
+package plugins_arm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/arm/pkg/arm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBicepDiagnostics_ShouldReadLocatedDiagnostics(t *testing.T) {
+	out := `WARNING: A new Bicep release is available: v0.24.24.
+/src/step1_network/main.bicep(3,7) : Error BCP018: Expected the "=" character at this location.
+/src/step1_network/main.bicep(12,1) : Warning no-unused-params: Parameter "tags" is declared but never used. [https://aka.ms/bicep/linter/no-unused-params]`
+
+	// act
+	diagnostics := arm.ParseBicepDiagnostics(out)
+
+	// assert
+	require.Equal(t, []arm.BicepDiagnostic{
+		{
+			Filename: "/src/step1_network/main.bicep",
+			Line:     3,
+			Column:   7,
+			Severity: "error",
+			Code:     "BCP018",
+			Message:  `Expected the "=" character at this location.`,
+		},
+		{
+			Filename: "/src/step1_network/main.bicep",
+			Line:     12,
+			Column:   1,
+			Severity: "warning",
+			Code:     "no-unused-params",
+			Message:  `Parameter "tags" is declared but never used. [https://aka.ms/bicep/linter/no-unused-params]`,
+		},
+	}, diagnostics)
+}
+
+func TestGetDeploymentTarget_ShouldReadBicepTargetScope(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runiac-arm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	exec := config.StepExecution{Dir: dir, Region: "centralus"}
+
+	tests := map[string]arm.Scope{
+		"param location string = 'centralus'\n":      arm.ResourceGroupScope,
+		"// targets\ntargetScope = 'subscription'\n": arm.SubscriptionScope,
+		"targetScope='managementGroup'\n":            arm.ManagementGroupScope,
+		"targetScope = 'tenant'\n":                   arm.TenantScope,
+	}
+
+	// a compiled main.json left next to the Bicep file does not determine the scope
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.json"), []byte(`{"$schema": "https://schema.management.azure.com/schemas/2019-08-01/tenantDeploymentTemplate.json#"}`), 0644))
+
+	for source, expected := range tests {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.bicep"), []byte(source), 0644))
+
+		exec.StepConfig = config.StepConfig{ArmManagementGroupID: "platform"}
+
+		// act
+		target, err := getDeploymentTarget(exec)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, expected, target.Scope, source)
+	}
+
+	require.Equal(t, ".temp/main.bicep.json", mainTemplateSource(exec))
+	require.True(t, ArmPlugin{}.IsStepDir(afero.NewOsFs(), dir))
+}
@@ -0,0 +1,67 @@
+package plugins_arm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/logging"
+)
+
+// readDeploymentOutputs sets the outputs of a deployment, as returned by `az deployment create` or `show`, as the
+// step's output variables. Values keep their ARM type, and secure values are registered as secrets so they are
+// redacted from all logs.
+func readDeploymentOutputs(resp string, output *config.StepOutput) error {
+	metadata := deployment{}
+	if err := json.Unmarshal([]byte(resp), &metadata); err != nil {
+		return fmt.Errorf("unable to read the outputs of the deployment: %w", err)
+	}
+
+	output.OutputVariables = map[string]interface{}{}
+	output.SensitiveOutputVariables = map[string]bool{}
+
+	for name, o := range metadata.Properties.Outputs {
+		value, err := outputValue(o)
+		if err != nil {
+			return fmt.Errorf("unable to read deployment output %s: %w", name, err)
+		}
+
+		output.OutputVariables[name] = value
+
+		if isSecureType(o.Type) {
+			output.SensitiveOutputVariables[name] = true
+			logging.RegisterSecretValue(value)
+		}
+	}
+
+	return nil
+}
+
+// outputValue decodes the value of a deployment output according to its ARM type. Integers are kept as integers
+// rather than decoded as floating point numbers, so large values are not rounded.
+func outputValue(o deploymentOutput) (interface{}, error) {
+	if len(o.Value) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(o.Value))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if n, ok := value.(json.Number); ok && strings.EqualFold(o.Type, "int") {
+		return n.Int64()
+	}
+
+	return value, nil
+}
+
+// isSecureType returns whether values of the ARM type are secure
+func isSecureType(outputType string) bool {
+	return strings.HasPrefix(strings.ToLower(outputType), "secure")
+}
@@ -0,0 +1,67 @@
+package plugins_arm
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/logging"
+	"github.com/stretchr/testify/require"
+)
+
+const createResponse = `{
+  "name": "runiac-project-default-step1_network-centralus",
+  "properties": {
+    "provisioningState": "Succeeded",
+    "outputs": {
+      "vnetId": {"type": "String", "value": "/subscriptions/0000/vnet"},
+      "subnetCount": {"type": "Int", "value": 9007199254740993},
+      "peered": {"type": "Bool", "value": true},
+      "tags": {"type": "Object", "value": {"owner": "platform"}},
+      "zones": {"type": "Array", "value": ["1", "2"]},
+      "adminPassword": {"type": "SecureString"}
+    },
+    "outputResources": [{"id": "/subscriptions/0000/vnet"}]
+  }
+}`
+
+func TestReadDeploymentOutputs_ShouldPreserveARMTypes(t *testing.T) {
+	output := config.StepOutput{}
+
+	// act
+	err := readDeploymentOutputs(createResponse, &output)
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, "/subscriptions/0000/vnet", output.OutputVariables["vnetId"])
+	require.Equal(t, int64(9007199254740993), output.OutputVariables["subnetCount"])
+	require.Equal(t, true, output.OutputVariables["peered"])
+	require.Equal(t, map[string]interface{}{"owner": "platform"}, output.OutputVariables["tags"])
+	require.Equal(t, []interface{}{"1", "2"}, output.OutputVariables["zones"])
+	require.Nil(t, output.OutputVariables["adminPassword"])
+	require.Equal(t, map[string]bool{"adminPassword": true}, output.SensitiveOutputVariables)
+}
+
+func TestReadDeploymentOutputs_ShouldRedactNestedSecureValues(t *testing.T) {
+	output := config.StepOutput{}
+
+	// act
+	err := readDeploymentOutputs(`{"properties": {"outputs": {
+	  "connection": {"type": "SecureObject", "value": {"user": "runiac-admin", "passwords": ["arm-secure-password"]}}
+	}}}`, &output)
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"connection": true}, output.SensitiveOutputVariables)
+	require.Equal(t, "user *** with password ***", logging.Redact("user runiac-admin with password arm-secure-password"))
+}
+
+func TestReadDeploymentOutputs_ShouldFailOnInvalidResponse(t *testing.T) {
+	output := config.StepOutput{}
+
+	err := readDeploymentOutputs("ERROR: deployment not found", &output)
+
+	var syntaxErr *json.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+}
@@ -0,0 +1,196 @@
+package plugins_arm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+)
+
+// runiacParametersFile is the generated parameter file of the runiac variables matching the template's parameters,
+// relative to the execution directory
+const runiacParametersFile = ".temp/runiac.parameters.json"
+
+// GetParameterFiles returns the parameter files within the step's directory that apply to the execution, relative to
+// the execution directory. Files are ordered from least to most specific, later files take precedence: common,
+// environment and deployment ring, the latter in lower case like the ring's var files.
+func GetParameterFiles(exec config.StepExecution) []string {
+	candidates := []string{
+		"main.parameters.json",
+		fmt.Sprintf("main.parameters.%s.json", exec.Environment),
+		fmt.Sprintf("main.parameters.ring_%s.json", strings.ToLower(exec.DeploymentRing)),
+	}
+
+	files := []string{}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(filepath.Join(exec.Dir, candidate)); err == nil && !contains(files, candidate) {
+			files = append(files, candidate)
+		}
+	}
+
+	return files
+}
+
+// GetRuniacVariables returns the runiac variables available to templates, including the outputs of previous steps
+func GetRuniacVariables(exec config.StepExecution) map[string]string {
+	vars := map[string]string{}
+
+	for k, v := range exec.OptionalStepParams {
+		vars[k] = v
+	}
+
+	vars["runiac_environment"] = exec.Environment
+	vars["runiac_account_id"] = exec.AccountID
+	vars["runiac_region"] = exec.Region
+	vars["runiac_app_version"] = exec.AppVersion
+	vars["runiac_namespace"] = exec.Namespace
+
+	return vars
+}
+
+// getDeploymentParameters returns the parameter files passed to the deployment of the execution. The runiac variables
+// matching a parameter of the template are passed first, so the step's parameter files take precedence over them.
+func getDeploymentParameters(exec config.StepExecution) ([]string, error) {
+	files := []string{}
+
+	declared, err := readTemplateParameters(filepath.Join(exec.Dir, mainTemplateSource(exec)))
+	if err != nil {
+		return nil, err
+	}
+
+	injected, err := runiacParameters(exec, declared)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(injected) > 0 {
+		names := []string{}
+		for name := range injected {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		exec.Logger.Infof("Passing runiac variables to template parameters: %s", strings.Join(names, ", "))
+
+		if err = writeParametersFile(filepath.Join(exec.Dir, runiacParametersFile), injected); err != nil {
+			return nil, err
+		}
+
+		files = append(files, runiacParametersFile)
+	}
+
+	paramFiles := GetParameterFiles(exec)
+	if len(paramFiles) > 0 {
+		exec.Logger.Infof("Using parameter files: %s", strings.Join(paramFiles, ", "))
+	}
+
+	return append(files, paramFiles...), nil
+}
+
+// readTemplateParameters returns the types of the parameters declared by a template, keyed by parameter name
+func readTemplateParameters(templateFile string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	template := struct {
+		Parameters map[string]struct {
+			Type string `json:"type"`
+		} `json:"parameters"`
+	}{}
+
+	if err = json.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("unable to read the parameters of %s: %w", templateFile, err)
+	}
+
+	types := map[string]string{}
+	for name, p := range template.Parameters {
+		types[name] = p.Type
+	}
+
+	return types, nil
+}
+
+// runiacParameters returns the runiac variables matching a declared parameter, case insensitively as ARM does, converted
+// to the parameter's type
+func runiacParameters(exec config.StepExecution, declared map[string]string) (map[string]interface{}, error) {
+	vars := map[string]string{}
+	for k, v := range GetRuniacVariables(exec) {
+		vars[strings.ToLower(k)] = v
+	}
+
+	params := map[string]interface{}{}
+
+	for name, paramType := range declared {
+		v, ok := vars[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+
+		value, err := parameterValue(paramType, v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to pass runiac variable %s to template parameter of type %s: %w", name, paramType, err)
+		}
+
+		params[name] = value
+	}
+
+	return params, nil
+}
+
+// parameterValue converts a runiac variable to the value of a template parameter of the type
+func parameterValue(paramType string, v string) (interface{}, error) {
+	switch strings.ToLower(paramType) {
+	case "int":
+		return strconv.Atoi(v)
+	case "bool":
+		return strconv.ParseBool(v)
+	case "object", "secureobject", "array":
+		var value interface{}
+		err := json.Unmarshal([]byte(v), &value)
+		return value, err
+	default:
+		return v, nil
+	}
+}
+
+// writeParametersFile writes the parameter values as a deployment parameters file. The values may include sensitive
+// outputs of previous steps, so they are passed by file rather than on the logged command line.
+func writeParametersFile(path string, values map[string]interface{}) error {
+	params := map[string]interface{}{}
+	for name, value := range values {
+		params[name] = map[string]interface{}{"value": value}
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"$schema":        "https://schema.management.azure.com/schemas/2019-04-01/deploymentParameters.json#",
+		"contentVersion": "1.0.0.0",
+		"parameters":     params,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
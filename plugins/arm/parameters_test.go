@@ -0,0 +1,105 @@
+package plugins_arm
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+const parametersTemplate = `{
+  "parameters": {
+    "runiac_Environment": {"type": "string"},
+    "runiac_region": {"type": "string"},
+    "network-subnet_count": {"type": "int"},
+    "network-tags": {"type": "object"},
+    "rgName": {"type": "string", "defaultValue": "rg"}
+  },
+  "resources": []
+}`
+
+func TestGetDeploymentParameters_ShouldInjectRuniacVariablesBeforeParameterFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runiac-arm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"main.json":                 parametersTemplate,
+		"main.parameters.json":      `{"parameters": {}}`,
+		"main.parameters.prod.json": `{"parameters": {}}`,
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	exec := config.StepExecution{
+		Dir:            dir,
+		Environment:    "prod",
+		DeploymentRing: "internal",
+		Region:         "centralus",
+		Logger:         logrus.NewEntry(logrus.New()),
+		OptionalStepParams: map[string]string{
+			"network-subnet_count": "3",
+			"network-tags":         `{"owner": "platform"}`,
+			"network-unused":       "ignored",
+		},
+	}
+
+	// act
+	files, err := getDeploymentParameters(exec)
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, []string{runiacParametersFile, "main.parameters.json", "main.parameters.prod.json"}, files)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, runiacParametersFile))
+	require.NoError(t, err)
+
+	params := struct {
+		Parameters map[string]struct {
+			Value interface{} `json:"value"`
+		} `json:"parameters"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, &params))
+
+	require.Len(t, params.Parameters, 4, "Only runiac variables matching a template parameter should be passed")
+	require.Equal(t, "prod", params.Parameters["runiac_Environment"].Value)
+	require.Equal(t, "centralus", params.Parameters["runiac_region"].Value)
+	require.Equal(t, float64(3), params.Parameters["network-subnet_count"].Value)
+	require.Equal(t, map[string]interface{}{"owner": "platform"}, params.Parameters["network-tags"].Value)
+}
+
+func TestGetDeploymentParameters_ShouldFailOnMistypedVariable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runiac-arm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.json"), []byte(parametersTemplate), 0644))
+
+	exec := config.StepExecution{
+		Dir:                dir,
+		Logger:             logrus.NewEntry(logrus.New()),
+		OptionalStepParams: map[string]string{"network-subnet_count": "three"},
+	}
+
+	_, err = getDeploymentParameters(exec)
+	require.Error(t, err)
+}
+
+func TestGetParameterFiles_ShouldMatchDeploymentRingInLowerCase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runiac-arm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"main.parameters.json", "main.parameters.ring_prod.json"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(`{"parameters": {}}`), 0644))
+	}
+
+	files := GetParameterFiles(config.StepExecution{Dir: dir, Environment: "nonprod", DeploymentRing: "PROD"})
+
+	require.Equal(t, []string{"main.parameters.json", "main.parameters.ring_prod.json"}, files)
+}
@@ -2,10 +2,14 @@ package arm
 
 type AzureRM interface {
 	ResourceDelete(options *Options, ids []string) (out string, err error)
-	SubCreate(options *Options, deploymentName string, accountID string, location string, file string) (out string, err error)
-	SubDelete(options *Options, deploymentName string, accountID string) (out string, err error)
-	SubShow(options *Options, deploymentName string, accountID string) (out string, err error)
-	SubWhatIf(options *Options, deploymentName string, accountID string, location string, file string) (out string, err error)
+	BicepBuild(options *Options, file string, outFile string) (out string, err error)
+	GroupCreate(options *Options, name string, location string, subscriptionID string) (out string, err error)
+	GroupExists(options *Options, name string, subscriptionID string) (bool, error)
+	DeploymentCreate(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error)
+	DeploymentDelete(options *Options, target DeploymentTarget) (out string, err error)
+	DeploymentShow(options *Options, target DeploymentTarget) (out string, err error)
+	DeploymentWhatIf(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error)
+	DeploymentValidate(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error)
 	Version(options *Options) (out string, err error)
 }
 
@@ -15,20 +19,36 @@ func (a AzureCLI) ResourceDelete(options *Options, ids []string) (out string, er
 	return ResourceDelete(options, ids)
 }
 
-func (a AzureCLI) SubCreate(options *Options, deploymentName string, accountID string, location string, file string) (out string, err error) {
-	return SubCreate(options, deploymentName, accountID, location, file)
+func (a AzureCLI) BicepBuild(options *Options, file string, outFile string) (out string, err error) {
+	return BicepBuild(options, file, outFile)
 }
 
-func (a AzureCLI) SubShow(options *Options, deploymentName string, accountID string) (out string, err error) {
-	return SubShow(options, deploymentName, accountID)
+func (a AzureCLI) GroupCreate(options *Options, name string, location string, subscriptionID string) (out string, err error) {
+	return GroupCreate(options, name, location, subscriptionID)
 }
 
-func (a AzureCLI) SubDelete(options *Options, deploymentName string, accountID string) (out string, err error) {
-	return SubDelete(options, deploymentName, accountID)
+func (a AzureCLI) GroupExists(options *Options, name string, subscriptionID string) (bool, error) {
+	return GroupExists(options, name, subscriptionID)
 }
 
-func (a AzureCLI) SubWhatIf(options *Options, deploymentName string, accountID string, location string, file string) (out string, err error) {
-	return SubWhatIf(options, deploymentName, accountID, location, file)
+func (a AzureCLI) DeploymentCreate(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error) {
+	return DeploymentCreate(options, target, file, parameterFiles)
+}
+
+func (a AzureCLI) DeploymentShow(options *Options, target DeploymentTarget) (out string, err error) {
+	return DeploymentShow(options, target)
+}
+
+func (a AzureCLI) DeploymentDelete(options *Options, target DeploymentTarget) (out string, err error) {
+	return DeploymentDelete(options, target)
+}
+
+func (a AzureCLI) DeploymentWhatIf(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error) {
+	return DeploymentWhatIf(options, target, file, parameterFiles)
+}
+
+func (a AzureCLI) DeploymentValidate(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error) {
+	return DeploymentValidate(options, target, file, parameterFiles)
 }
 
 func (a AzureCLI) Version(options *Options) (out string, err error) {
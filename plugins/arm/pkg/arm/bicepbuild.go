@@ -0,0 +1,86 @@
+package arm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bicepDiagnosticLine matches a diagnostic of the Bicep compiler, e.g.
+// /src/main.bicep(3,7) : Error BCP018: Expected the "=" character at this location.
+var bicepDiagnosticLine = regexp.MustCompile(`^(.+)\((\d+),(\d+)\)\s*:\s*(Error|Warning|Info)\s+([^:\s]+)\s*:\s*(.*)$`)
+
+// BicepDiagnostic is an error or warning reported by the Bicep compiler
+type BicepDiagnostic struct {
+	Filename string
+	Line     int
+	Column   int
+	Severity string // error, warning or info
+	Code     string // e.g. BCP018, or the linter rule
+	Message  string
+}
+
+func (d BicepDiagnostic) String() string {
+	return fmt.Sprintf("%s(%d,%d): %s %s: %s", d.Filename, d.Line, d.Column, d.Severity, d.Code, d.Message)
+}
+
+// BicepDiagnosticsError is the error of a failed Bicep build with the error diagnostics the compiler reported
+type BicepDiagnosticsError struct {
+	Err         error
+	Diagnostics []BicepDiagnostic
+}
+
+func (e *BicepDiagnosticsError) Error() string {
+	lines := []string{e.Err.Error()}
+
+	for _, d := range e.Diagnostics {
+		lines = append(lines, d.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (e *BicepDiagnosticsError) Unwrap() error {
+	return e.Err
+}
+
+// BicepBuild compiles the Bicep file to an ARM template at the out file
+func BicepBuild(options *Options, file string, outFile string) (out string, err error) {
+	args := []string{
+		"bicep",
+		"build",
+		"--file",
+		file,
+		"--outfile",
+		outFile,
+	}
+
+	return RunAzureCLICommand(false, options, args...)
+}
+
+// ParseBicepDiagnostics returns the diagnostics within the output of a Bicep build, other lines are ignored
+func ParseBicepDiagnostics(out string) []BicepDiagnostic {
+	diagnostics := []BicepDiagnostic{}
+
+	for _, line := range strings.Split(out, "\n") {
+		m := bicepDiagnosticLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+
+		lineNumber, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+
+		diagnostics = append(diagnostics, BicepDiagnostic{
+			Filename: m[1],
+			Line:     lineNumber,
+			Column:   column,
+			Severity: strings.ToLower(m[4]),
+			Code:     m[5],
+			Message:  m[6],
+		})
+	}
+
+	return diagnostics
+}
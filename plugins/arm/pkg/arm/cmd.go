@@ -22,3 +22,20 @@ func RunAzureCLICommand(streamOutput bool, options *Options, additionalArgs ...s
 
 	return shell.RunShellCommandAndGetOutput(cmd)
 }
+
+// RunAzureCLICommandAndGetStdout runs an Azure CLI command with machine readable output, returning only its stdout so
+// warnings written to stderr do not corrupt it
+func RunAzureCLICommandAndGetStdout(options *Options, additionalArgs ...string) (string, error) {
+	cmd := shell.Command{
+		Command:           options.AzureCLIBinary,
+		Args:              additionalArgs,
+		WorkingDir:        options.AzureCLIDir,
+		Env:               options.EnvVars,
+		OutputMaxLineSize: options.OutputMaxLineSize,
+		NonInteractive:    true,
+		SensitiveArgs:     false,
+		Logger:            options.Logger,
+	}
+
+	return shell.RunShellCommandAndGetStdout(cmd)
+}
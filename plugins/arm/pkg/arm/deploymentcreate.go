@@ -0,0 +1,5 @@
+package arm
+
+func DeploymentCreate(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error) {
+	return RunAzureCLICommand(true, options, target.templateArgs("create", file, parameterFiles)...)
+}
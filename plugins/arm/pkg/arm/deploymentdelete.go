@@ -0,0 +1,5 @@
+package arm
+
+func DeploymentDelete(options *Options, target DeploymentTarget) (out string, err error) {
+	return RunAzureCLICommand(true, options, target.deploymentArgs("delete")...)
+}
@@ -0,0 +1,5 @@
+package arm
+
+func DeploymentValidate(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error) {
+	return RunAzureCLICommand(false, options, target.templateArgs("validate", file, parameterFiles)...)
+}
@@ -0,0 +1,8 @@
+package arm
+
+// DeploymentWhatIf returns the changes the deployment of the template would make as JSON
+func DeploymentWhatIf(options *Options, target DeploymentTarget, file string, parameterFiles []string) (out string, err error) {
+	args := append(target.templateArgs("what-if", file, parameterFiles), "--no-pretty-print")
+
+	return RunAzureCLICommandAndGetStdout(options, args...)
+}
@@ -0,0 +1,13 @@
+package arm
+
+// FormatParameterFilesAsArgs formats the given parameter files as args for the Azure CLI (e.g. of the format
+// --parameters @main.parameters.json). Later files take precedence.
+func FormatParameterFilesAsArgs(files []string) []string {
+	args := []string{}
+
+	for _, file := range files {
+		args = append(args, "--parameters", "@"+file)
+	}
+
+	return args
+}
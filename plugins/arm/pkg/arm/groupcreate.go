@@ -0,0 +1,17 @@
+package arm
+
+// GroupCreate creates the resource group, or updates it when it already exists
+func GroupCreate(options *Options, name string, location string, subscriptionID string) (out string, err error) {
+	args := []string{
+		"group",
+		"create",
+		"--name",
+		name,
+		"--location",
+		location,
+		"--subscription",
+		subscriptionID,
+	}
+
+	return RunAzureCLICommand(true, options, args...)
+}
@@ -0,0 +1,22 @@
+package arm
+
+import "strings"
+
+// GroupExists returns whether the resource group exists
+func GroupExists(options *Options, name string, subscriptionID string) (bool, error) {
+	args := []string{
+		"group",
+		"exists",
+		"--name",
+		name,
+		"--subscription",
+		subscriptionID,
+	}
+
+	out, err := RunAzureCLICommand(false, options, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(out) == "true", nil
+}
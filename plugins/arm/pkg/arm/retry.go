@@ -0,0 +1,13 @@
+package arm
+
+// DefaultRetryableAzureCLIErrors are transient errors of Azure CLI deployment commands that are worth retrying. The
+// keys are a regexp to match against the command output and the values are the reason displayed when it is matched.
+var DefaultRetryableAzureCLIErrors = map[string]string{
+	"(?i)toomanyrequests|(status ?code|status)[ =:]*429\\b":       "Azure Resource Manager rate limit exceeded.",
+	"(?i)(status ?code|status)[ =:]*(500|502|503|504)\\b":         "Azure Resource Manager returned a transient HTTP error.",
+	"(?i)(serviceunavailable|internalservererror|gatewaytimeout)": "Azure Resource Manager is temporarily unavailable.",
+	"(?i)anotheroperationinprogress|deploymentactive":             "Another deployment is in progress in the scope.",
+	"(?i)retryableerror":                              "Azure Resource Manager reported a retryable error.",
+	"(?i)connection reset by peer|connection aborted": "Network connection was reset.",
+	"(?i)read timed out|i/o timeout":                  "Network request timed out.",
+}
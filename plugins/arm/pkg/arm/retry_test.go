@@ -0,0 +1,38 @@
+package arm
+
+import (
+	"testing"
+
+	"github.com/optum/runiac/pkg/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRetryableAzureCLIErrors_ShouldOnlyMatchTransientErrors(t *testing.T) {
+	retryableErrors, err := retry.CompileRetryableErrors(DefaultRetryableAzureCLIErrors)
+	require.NoError(t, err)
+
+	transient := []string{
+		"ERROR: (TooManyRequests) The request is being throttled.",
+		"Status: 503 (Service Unavailable)",
+		"ERROR: (AnotherOperationInProgress) Another operation on this or dependent resource is in progress.",
+		"dial tcp 20.190.128.1:443: i/o timeout",
+		"HTTPSConnectionPool(host='management.azure.com', port=443): Read timed out. (read timeout=300)",
+	}
+
+	for _, message := range transient {
+		_, ok := retry.MatchRetryableError(retryableErrors, message)
+		assert.True(t, ok, message)
+	}
+
+	permanent := []string{
+		"ERROR: (InvalidTemplate) Deployment template validation failed.",
+		"ERROR: (DeploymentFailed) The resource operation completed with terminal provisioning state 'Failed'.",
+		"ERROR: (Conflict) The operation timed out waiting for the VM agent to report status.",
+	}
+
+	for _, message := range permanent {
+		_, ok := retry.MatchRetryableError(retryableErrors, message)
+		assert.False(t, ok, message)
+	}
+}
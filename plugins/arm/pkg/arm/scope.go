@@ -0,0 +1,95 @@
+package arm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope is the scope a template is deployed at, named as the az deployment command group of the scope
+type Scope string
+
+const (
+	ResourceGroupScope   Scope = "group"
+	SubscriptionScope    Scope = "sub"
+	ManagementGroupScope Scope = "mg"
+	TenantScope          Scope = "tenant"
+)
+
+// ParseScope returns the scope of the given name, either the az deployment command group or the scope's full name
+// (e.g. mg or management_group)
+func ParseScope(name string) (Scope, error) {
+	switch strings.ReplaceAll(strings.ToLower(name), "-", "_") {
+	case "group", "resource_group", "resourcegroup":
+		return ResourceGroupScope, nil
+	case "sub", "subscription":
+		return SubscriptionScope, nil
+	case "mg", "management_group", "managementgroup":
+		return ManagementGroupScope, nil
+	case "tenant":
+		return TenantScope, nil
+	}
+
+	return "", fmt.Errorf("unknown deployment scope %s, expected resource_group, subscription, management_group or tenant", name)
+}
+
+// ParseMode returns the deployment mode of the given name, Complete or Incremental, case insensitively
+func ParseMode(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "complete":
+		return "Complete", nil
+	case "incremental":
+		return "Incremental", nil
+	}
+
+	return "", fmt.Errorf("unknown deployment mode %s, expected Complete or Incremental", name)
+}
+
+// DeploymentTarget identifies a deployment and the scope it is deployed at
+type DeploymentTarget struct {
+	Scope             Scope
+	Name              string // Name of the deployment
+	Location          string // Location the deployment metadata is stored in, not used at the resource group scope
+	SubscriptionID    string // Subscription of the subscription and resource group scopes
+	ResourceGroup     string // Resource group of the resource group scope
+	ManagementGroupID string // Management group of the management group scope
+	Mode              string // Deployment mode of the resource group scope, Complete or Incremental. Defaults to Incremental
+}
+
+// deploymentArgs returns the args of an az deployment command identifying the target's deployment
+func (target DeploymentTarget) deploymentArgs(command string) []string {
+	args := []string{
+		"deployment",
+		string(target.Scope),
+		command,
+		"--name",
+		target.Name,
+	}
+
+	switch target.Scope {
+	case ResourceGroupScope:
+		args = append(args, "--resource-group", target.ResourceGroup, "--subscription", target.SubscriptionID)
+	case SubscriptionScope:
+		args = append(args, "--subscription", target.SubscriptionID)
+	case ManagementGroupScope:
+		args = append(args, "--management-group-id", target.ManagementGroupID)
+	}
+
+	return args
+}
+
+// templateArgs returns the args of an az deployment command deploying the template file to the target
+func (target DeploymentTarget) templateArgs(command string, file string, parameterFiles []string) []string {
+	args := target.deploymentArgs(command)
+
+	if target.Scope == ResourceGroupScope {
+		if target.Mode != "" {
+			args = append(args, "--mode", target.Mode)
+		}
+	} else {
+		args = append(args, "--location", target.Location)
+	}
+
+	args = append(args, "--template-file", file)
+
+	return append(args, FormatParameterFilesAsArgs(parameterFiles)...)
+}
@@ -1,7 +1,10 @@
 package plugins_arm
 
 import (
+	"path/filepath"
+
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 	"github.com/optum/runiac/plugins/arm/pkg/arm"
 )
 
@@ -28,3 +31,13 @@ func (info ArmPlugin) Initialize(logger *logrus.Entry) {
 		logger.Info("Binary: ", out)
 	}
 }
+
+// IsStepDir returns whether the directory contains an ARM or Bicep template
+func (info ArmPlugin) IsStepDir(fs afero.Fs, dir string) bool {
+	for _, name := range []string{defaultTemplateFile, bicepTemplateFile} {
+		if exists, _ := afero.Exists(fs, filepath.Join(dir, name)); exists {
+			return true
+		}
+	}
+	return false
+}
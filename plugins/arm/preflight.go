@@ -0,0 +1,70 @@
+package plugins_arm
+
+import (
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+)
+
+// CheckStep validates the execution's template with az deployment validate, which checks the template and its
+// parameters against the deployment's scope without deploying it
+func (stepper ArmStepper) CheckStep(exec config.StepExecution) []config.PreflightIssue {
+	issue := func(check string, message string) []config.PreflightIssue {
+		return []config.PreflightIssue{{
+			StepID:           exec.StepID,
+			RegionDeployType: exec.RegionDeployType,
+			Dir:              exec.Dir,
+			Check:            check,
+			Message:          message,
+		}}
+	}
+
+	options, err := getCommonOptions(exec)
+	if err != nil {
+		return issue("options", err.Error())
+	}
+
+	options.Logger = options.Logger.WithField("az", "preflight")
+
+	target, err := getDeploymentTarget(exec)
+	if err != nil {
+		return issue("scope", err.Error())
+	}
+
+	if err = buildBicep(exec, options, &config.StepOutput{}); err != nil {
+		return issue("bicep", err.Error())
+	}
+
+	mainTemplateFile, err := parseMainTemplate(exec)
+	if err != nil {
+		return issue("template", err.Error())
+	}
+
+	parameterFiles, err := getDeploymentParameters(exec)
+	if err != nil {
+		return issue("parameters", err.Error())
+	}
+
+	groupExists, err := resourceGroupExists(options, target)
+	if err != nil {
+		return issue("resource group", err.Error())
+	}
+
+	if !groupExists {
+		// the resource group is created by the step's first deployment, so there is nothing to validate against yet
+		options.Logger.Warnf("Skipping validation, resource group %s does not exist yet", target.ResourceGroup)
+		return []config.PreflightIssue{}
+	}
+
+	out, err := retryCommand(exec, options, "validate", func(attempt int) (string, error) {
+		return azureCLI.DeploymentValidate(options, target, mainTemplateFile, parameterFiles)
+	})
+	if err != nil {
+		if strings.TrimSpace(out) == "" {
+			out = err.Error()
+		}
+		return issue("validate", out)
+	}
+
+	return []config.PreflightIssue{}
+}
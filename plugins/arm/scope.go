@@ -0,0 +1,119 @@
+package plugins_arm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/arm/pkg/arm"
+)
+
+// schemaScopes are the deployment scopes of the template schemas, matched by the schema's file name. Resource group
+// schemas are not inferred, such templates have been deployed at the subscription scope and deploying them to a resource
+// group would create their resources anew, they opt in with arm_scope in runiac.yml.
+var schemaScopes = map[string]arm.Scope{
+	"subscriptiondeploymenttemplate.json":    arm.SubscriptionScope,
+	"managementgroupdeploymenttemplate.json": arm.ManagementGroupScope,
+	"tenantdeploymenttemplate.json":          arm.TenantScope,
+}
+
+// getDeploymentTarget returns the deployment of the execution's template. The scope is read from the step's
+// runiac.yml, otherwise from the targetScope of Bicep templates or the $schema of JSON templates, defaulting to the
+// subscription scope for JSON templates, including resource group schemas.
+func getDeploymentTarget(exec config.StepExecution) (arm.DeploymentTarget, error) {
+	target := arm.DeploymentTarget{
+		Name:           createDeploymentName(exec),
+		Location:       exec.Region,
+		SubscriptionID: exec.AccountID,
+	}
+
+	var err error
+
+	if exec.StepConfig.ArmScope != "" {
+		target.Scope, err = arm.ParseScope(exec.StepConfig.ArmScope)
+	} else if isBicepStep(exec) {
+		target.Scope, err = readBicepScope(filepath.Join(exec.Dir, bicepTemplateFile))
+	} else {
+		target.Scope, err = readTemplateScope(filepath.Join(exec.Dir, defaultTemplateFile))
+	}
+	if err != nil {
+		return target, err
+	}
+
+	if exec.StepConfig.ArmDeploymentMode != "" {
+		if target.Scope != arm.ResourceGroupScope {
+			return target, fmt.Errorf("the deployment mode is only supported by resource group scoped templates, the template is deployed at the %s scope", target.Scope)
+		}
+
+		if target.Mode, err = arm.ParseMode(exec.StepConfig.ArmDeploymentMode); err != nil {
+			return target, err
+		}
+	}
+
+	switch target.Scope {
+	case arm.ResourceGroupScope:
+		target.ResourceGroup = resourceGroupName(exec)
+	case arm.ManagementGroupScope:
+		if exec.StepConfig.ArmManagementGroupID == "" {
+			return target, fmt.Errorf("arm_management_group_id must be set in runiac.yml to deploy management group scoped templates")
+		}
+		target.ManagementGroupID = exec.StepConfig.ArmManagementGroupID
+	}
+
+	return target, nil
+}
+
+// readTemplateScope returns the deployment scope of a template's $schema, templates without a known schema and resource
+// group templates are deployed at the subscription scope
+func readTemplateScope(templateFile string) (arm.Scope, error) {
+	data, err := ioutil.ReadFile(templateFile)
+	if err != nil {
+		return "", err
+	}
+
+	template := struct {
+		Schema string `json:"$schema"`
+	}{}
+
+	if err = json.Unmarshal(data, &template); err != nil {
+		return "", fmt.Errorf("unable to read the $schema of %s: %w", templateFile, err)
+	}
+
+	schema := strings.ToLower(strings.TrimSuffix(template.Schema, "#"))
+
+	if scope, ok := schemaScopes[schema[strings.LastIndex(schema, "/")+1:]]; ok {
+		return scope, nil
+	}
+
+	return arm.SubscriptionScope, nil
+}
+
+// resourceGroupName returns the resource group of the execution, unique per namespace and region as terraform
+// workspaces are. The base name defaults to the step's project, track and step.
+func resourceGroupName(exec config.StepExecution) string {
+	name := exec.StepConfig.ArmResourceGroup
+
+	if name == "" {
+		name = fmt.Sprintf("%s-%s-%s", exec.Project, exec.TrackName, exec.StepName)
+	}
+
+	name = fmt.Sprintf("%s-%s", name, exec.Region)
+
+	if exec.Namespace != "" {
+		name = fmt.Sprintf("%s-%s", exec.Namespace, name)
+	}
+
+	return name
+}
+
+// resourceGroupExists returns whether the target's resource group exists, deployments at other scopes do not need one
+func resourceGroupExists(options *arm.Options, target arm.DeploymentTarget) (bool, error) {
+	if target.Scope != arm.ResourceGroupScope {
+		return true, nil
+	}
+
+	return azureCLI.GroupExists(options, target.ResourceGroup, target.SubscriptionID)
+}
@@ -0,0 +1,111 @@
+package plugins_arm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/arm/pkg/arm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDeploymentTarget_ShouldReadScopeFromSchemaOrStepConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runiac-arm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name     string
+		schema   string
+		config   config.StepConfig
+		expected arm.DeploymentTarget
+	}{
+		{
+			name:     "resource group schema",
+			schema:   "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+			expected: arm.DeploymentTarget{Scope: arm.SubscriptionScope},
+		},
+		{
+			name:   "resource group schema and config",
+			schema: "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+			config: config.StepConfig{ArmScope: "resource_group", ArmDeploymentMode: "complete"},
+			expected: arm.DeploymentTarget{
+				Scope:         arm.ResourceGroupScope,
+				ResourceGroup: "ns-project-core-step1_network-centralus",
+				Mode:          "Complete",
+			},
+		},
+		{
+			name:     "subscription schema",
+			schema:   "https://schema.management.azure.com/schemas/2018-05-01/subscriptionDeploymentTemplate.json#",
+			expected: arm.DeploymentTarget{Scope: arm.SubscriptionScope},
+		},
+		{
+			name:     "unknown schema",
+			expected: arm.DeploymentTarget{Scope: arm.SubscriptionScope},
+		},
+		{
+			name:   "management group config",
+			schema: "https://schema.management.azure.com/schemas/2018-05-01/subscriptionDeploymentTemplate.json#",
+			config: config.StepConfig{ArmScope: "management_group", ArmManagementGroupID: "platform"},
+			expected: arm.DeploymentTarget{
+				Scope:             arm.ManagementGroupScope,
+				ManagementGroupID: "platform",
+			},
+		},
+		{
+			name:   "resource group config",
+			schema: "https://schema.management.azure.com/schemas/2019-08-01/tenantDeploymentTemplate.json#",
+			config: config.StepConfig{ArmScope: "resource_group", ArmResourceGroup: "rg-network"},
+			expected: arm.DeploymentTarget{
+				Scope:         arm.ResourceGroupScope,
+				ResourceGroup: "ns-rg-network-centralus",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.json"), []byte(`{"$schema": "`+test.schema+`", "resources": []}`), 0644))
+
+			exec := config.StepExecution{
+				Dir:        dir,
+				Project:    "project",
+				TrackName:  "core",
+				StepName:   "step1_network",
+				Region:     "centralus",
+				AccountID:  "0000",
+				Namespace:  "ns",
+				StepConfig: test.config,
+			}
+
+			// act
+			target, err := getDeploymentTarget(exec)
+
+			// assert
+			require.NoError(t, err)
+
+			test.expected.Name = "runiac-project-core-step1_network-centralus"
+			test.expected.Location = "centralus"
+			test.expected.SubscriptionID = "0000"
+			require.Equal(t, test.expected, target)
+		})
+	}
+}
+
+func TestGetDeploymentTarget_ShouldRejectModeOutsideResourceGroupScope(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runiac-arm-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.json"), []byte(`{"resources": []}`), 0644))
+
+	_, err = getDeploymentTarget(config.StepExecution{
+		Dir:        dir,
+		StepConfig: config.StepConfig{ArmDeploymentMode: "Complete"},
+	})
+
+	require.Error(t, err)
+}
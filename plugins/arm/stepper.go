@@ -3,9 +3,11 @@ package plugins_arm
 import (
 	"fmt"
 	"encoding/json"
+	"strings"
 
 	"github.com/spf13/afero"
 	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/retry"
 	"github.com/optum/runiac/plugins/arm/pkg/arm"
 )
 
@@ -24,7 +26,6 @@ func (stepper ArmStepper) ExecuteStepDestroy(exec config.StepExecution) (output
 	output.StepName = exec.StepName
 	output.Status = config.Fail
 	var options *arm.Options
-	deploymentName := createDeploymentName(exec)
 
 	options, output.Err = getCommonOptions(exec)
 	if output.Err != nil {
@@ -32,10 +33,18 @@ func (stepper ArmStepper) ExecuteStepDestroy(exec config.StepExecution) (output
 		return
 	}
 
+	target, err := getDeploymentTarget(exec)
+	if err != nil {
+		output.Err = err
+		options.Logger.WithError(err).Error("Unable to determine the deployment scope of the template")
+		return
+	}
+
 	// find the metadata associated with the last deployment
-	resp, err := azureCLI.SubShow(options, deploymentName, exec.AccountID)
+	resp, err := azureCLI.DeploymentShow(options, target)
 	if err != nil {
-		options.Logger.WithError(output.Err).Error("Failed to plan template deployment")
+		output.Err = err
+		options.Logger.WithError(err).Error("Failed to read last template deployment")
 		return
 	}
 
@@ -43,7 +52,8 @@ func (stepper ArmStepper) ExecuteStepDestroy(exec config.StepExecution) (output
 	metadata := deployment{}
 	err = json.Unmarshal([]byte(resp), &metadata)
 	if err != nil {
-		options.Logger.WithError(output.Err).Error("Failed to read last template deployment")
+		output.Err = err
+		options.Logger.WithError(err).Error("Failed to read last template deployment")
 		return
 	}
 
@@ -56,14 +66,16 @@ func (stepper ArmStepper) ExecuteStepDestroy(exec config.StepExecution) (output
 	// delete all created resources
 	_, err = azureCLI.ResourceDelete(options, ids)
 	if err != nil {
-		options.Logger.WithError(output.Err).Error("Failed to delete resources")
+		output.Err = err
+		options.Logger.WithError(err).Error("Failed to delete resources")
 		return
 	}
 
 	// delete deployment metadata
-	_, err = azureCLI.SubDelete(options, deploymentName, exec.AccountID)
+	_, err = azureCLI.DeploymentDelete(options, target)
 	if err != nil {
-		options.Logger.WithError(output.Err).Error("Failed to delete deployment metadata")
+		output.Err = err
+		options.Logger.WithError(err).Error("Failed to delete deployment metadata")
 		return
 	}
 
@@ -78,7 +90,6 @@ func (stepper ArmStepper) ExecuteStep(exec config.StepExecution) (output config.
 	output.StepName = exec.StepName
 	output.Status = config.Fail
 	var options *arm.Options
-	deploymentName := createDeploymentName(exec)
 
 	options, output.Err = getCommonOptions(exec)
 	if output.Err != nil {
@@ -86,24 +97,119 @@ func (stepper ArmStepper) ExecuteStep(exec config.StepExecution) (output config.
 		return
 	}
 
+	target, err := getDeploymentTarget(exec)
+	if err != nil {
+		output.Err = err
+		options.Logger.WithError(err).Error("Unable to determine the deployment scope of the template")
+		return
+	}
+
+	options.Logger.Infof("Deploying the template at the %s scope", target.Scope)
+
+	if err = buildBicep(exec, options, &output); err != nil {
+		output.Err = err
+		options.Logger.WithError(err).Error("Unable to build Bicep template for step execution")
+		return
+	}
+
 	mainTemplateFile, err := parseMainTemplate(exec)
 	if err != nil {
-		options.Logger.WithError(output.Err).Error("Unable to parse template for step execution")
+		output.Err = err
+		options.Logger.WithError(err).Error("Unable to parse template for step execution")
+		return
+	}
+
+	parameterFiles, err := getDeploymentParameters(exec)
+	if err != nil {
+		output.Err = err
+		options.Logger.WithError(err).Error("Unable to prepare template parameters for step execution")
 		return
 	}
 
-	_, err = azureCLI.SubWhatIf(options, deploymentName, exec.AccountID, exec.Region, mainTemplateFile)
+	groupExists, err := resourceGroupExists(options, target)
 	if err != nil {
-		options.Logger.WithError(output.Err).Error("Failed to plan template deployment")
+		output.Err = err
+		options.Logger.WithError(err).Errorf("Unable to find resource group %s", target.ResourceGroup)
 		return
 	}
 
+	if !groupExists && !exec.DryRun {
+		options.Logger.Infof("Creating resource group %s", target.ResourceGroup)
+
+		_, err = azureCLI.GroupCreate(options, target.ResourceGroup, target.Location, target.SubscriptionID)
+		if err != nil {
+			output.Err = err
+			options.Logger.WithError(err).Errorf("Failed to create resource group %s", target.ResourceGroup)
+			return
+		}
+
+		groupExists = true
+	}
+
+	if groupExists {
+		resp, err := retryCommand(exec, options, "what-if", func(attempt int) (string, error) {
+			return azureCLI.DeploymentWhatIf(options, target, mainTemplateFile, parameterFiles)
+		})
+		if err != nil {
+			output.Err = err
+			options.Logger.WithError(err).Error("Failed to plan template deployment")
+			return
+		}
+
+		result, err := readWhatIf(resp)
+		if err != nil {
+			output.Err = err
+			options.Logger.WithError(err).Error("Failed to plan template deployment")
+			return
+		}
+
+		summary := summarizeWhatIf(result)
+		output.Plan = &summary
+
+		deletes := []string{}
+		for _, c := range summary.ResourceChanges {
+			options.Logger.Info(fmt.Sprintf("%s, %s, %s: [%s]", c.Address, c.Type, c.Name, c.Action))
+
+			if c.Action == config.PlanActionDelete {
+				deletes = append(deletes, c.Address)
+			}
+		}
+
+		options.Logger.Infof("What-if: %d to add, %d to change, %d to destroy", summary.Add, summary.Change, summary.Destroy)
+
+		if exec.DryRun && exec.FailOnDestroy && len(deletes) > 0 {
+			output.Err = fmt.Errorf("the deployment deletes %d resources: %s", len(deletes), strings.Join(deletes, ", "))
+			options.Logger.WithError(output.Err).Error("Failing dry run with destructive changes")
+			return
+		}
+	} else {
+		options.Logger.Infof("Skipping what-if, resource group %s does not exist yet and is created by the deployment", target.ResourceGroup)
+	}
+
 	if exec.DryRun {
 		options.Logger.Info("---------- Skipping create, this is a dry run ---------- ")
+
+		// the outputs of the last deployment are still passed to the following steps, when it exists
+		resp, err := azureCLI.DeploymentShow(options, target)
+		if err == nil {
+			err = readDeploymentOutputs(resp, &output)
+		}
+		if err != nil {
+			options.Logger.WithError(err).Warn("Unable to read the outputs of the last template deployment")
+		}
 	} else {
-		_, err = azureCLI.SubCreate(options, deploymentName, exec.AccountID, exec.Region, mainTemplateFile)
+		resp, err := retryCommand(exec, options, "create", func(attempt int) (string, error) {
+			return azureCLI.DeploymentCreate(options, target, mainTemplateFile, parameterFiles)
+		})
 		if err != nil {
-			options.Logger.WithError(output.Err).Error("Failed to deploy template")
+			output.Err = err
+			options.Logger.WithError(err).Error("Failed to deploy template")
+			return
+		}
+
+		if err = readDeploymentOutputs(resp, &output); err != nil {
+			output.Err = err
+			options.Logger.WithError(err).Error("Failed to read template deployment outputs")
 			return
 		}
 	}
@@ -118,6 +224,62 @@ func (stepper ArmStepper) ExecuteStepTests(exec config.StepExecution) (output co
 	return
 }
 
+// PrepareStepCommand returns the Azure CLI environment of the execution, defaulting commands to the execution's region
+func (stepper ArmStepper) PrepareStepCommand(exec config.StepExecution) (config.StepCommand, error) {
+	options, err := getCommonOptions(exec)
+	if err != nil {
+		return config.StepCommand{}, err
+	}
+
+	env := map[string]string{
+		"AZURE_DEFAULTS_LOCATION": exec.Region,
+	}
+	for k, v := range options.EnvVars {
+		env[k] = v
+	}
+
+	return config.StepCommand{
+		Binary: options.AzureCLIBinary,
+		Dir:    options.AzureCLIDir,
+		Env:    env,
+	}, nil
+}
+
+// ReadStepOutputs reads the outputs of the execution's last template deployment
+func (stepper ArmStepper) ReadStepOutputs(exec config.StepExecution) (output config.StepOutput) {
+	output.RegionDeployType = exec.RegionDeployType
+	output.Region = exec.Region
+	output.StepName = exec.StepName
+	output.Status = config.Fail
+
+	options, err := getCommonOptions(exec)
+	if err != nil {
+		output.Err = err
+		return
+	}
+
+	target, err := getDeploymentTarget(exec)
+	if err != nil {
+		output.Err = err
+		return
+	}
+
+	resp, err := retryCommand(exec, options, "show", func(attempt int) (string, error) {
+		return azureCLI.DeploymentShow(options, target)
+	})
+	if err == nil {
+		err = readDeploymentOutputs(resp, &output)
+	}
+	if err != nil {
+		output.Err = err
+		options.Logger.WithError(err).Error("Failed to read the outputs of the last template deployment")
+		return
+	}
+
+	output.Status = config.Success
+	return
+}
+
 func getCommonOptions(exec config.StepExecution) (options *arm.Options, err error) {
 	options = &arm.Options{
 		AzureCLIBinary:           "az",
@@ -129,6 +291,20 @@ func getCommonOptions(exec config.StepExecution) (options *arm.Options, err erro
 	return
 }
 
+// retryBackoff is the backoff between retries of an Azure CLI command
+var retryBackoff = retry.DefaultBackoff
+
+// retryCommand runs a single Azure CLI command, retrying it only when it fails with an error matching the ARM
+// retryable errors catalog or the user configured patterns. Other errors fail the command immediately.
+func retryCommand(exec config.StepExecution, options *arm.Options, command string, action func(attempt int) (string, error)) (string, error) {
+	retryableErrors, err := retry.MergeRetryableErrors(arm.DefaultRetryableAzureCLIErrors, exec.RetryableErrors)
+	if err != nil {
+		return "", err
+	}
+
+	return retry.DoWithRetryableErrors(fmt.Sprintf("az deployment %s", command), retryableErrors, exec.MaxRetries, retryBackoff, options.Logger, action)
+}
+
 func createDeploymentName(exec config.StepExecution) string {
 	return fmt.Sprintf("runiac-%s-%s-%s-%s", exec.Project, exec.TrackName, exec.StepName, exec.Region)
 }
@@ -136,13 +312,13 @@ func createDeploymentName(exec config.StepExecution) string {
 func parseMainTemplate(exec config.StepExecution) (string, error) {
 	fs := afero.NewOsFs()
 	
-	err := fs.Mkdir(fmt.Sprintf("%s/.temp", exec.Dir), 0755)
+	err := fs.MkdirAll(fmt.Sprintf("%s/.temp", exec.Dir), 0755)
 	if err != nil {
 		exec.Logger.WithError(err).Error(err)
 		return "", err
 	}
 
-	data, err := afero.ReadFile(fs, fmt.Sprintf("%s/main.json", exec.Dir))
+	data, err := afero.ReadFile(fs, fmt.Sprintf("%s/%s", exec.Dir, mainTemplateSource(exec)))
 	if err != nil {
 		exec.Logger.WithError(err).Error(err)
 		return "", err
@@ -156,7 +332,8 @@ func parseMainTemplate(exec config.StepExecution) (string, error) {
 		return "", err
 	}
 
-	resources := template["resources"].([]interface{})
+	// templates with symbolic resource names, e.g. compiled from Bicep, declare resources as an object
+	resources, _ := template["resources"].([]interface{})
 	for _, item := range resources {
 		resource := item.(map[string]interface{})
 
@@ -0,0 +1,199 @@
+package plugins_arm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+)
+
+var whatIfArrayIndex = regexp.MustCompile(`^[0-9]+$`)
+
+// Struct representing the result of az deployment what-if
+type whatIfResult struct {
+	Status  string         `json:"status"`
+	Changes []whatIfChange `json:"changes"`
+	Error   *whatIfError   `json:"error"`
+}
+
+// Struct representing the error of a failed what-if
+type whatIfError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Struct representing the predicted change of a single resource
+type whatIfChange struct {
+	ResourceID        string                 `json:"resourceId"`
+	ChangeType        string                 `json:"changeType"`
+	UnsupportedReason string                 `json:"unsupportedReason"`
+	Delta             []whatIfPropertyChange `json:"delta"`
+}
+
+// Struct representing the predicted change of a resource property, the changes of array items are its children
+type whatIfPropertyChange struct {
+	Path               string                 `json:"path"`
+	PropertyChangeType string                 `json:"propertyChangeType"`
+	Before             interface{}            `json:"before"`
+	After              interface{}            `json:"after"`
+	Children           []whatIfPropertyChange `json:"children"`
+}
+
+// readWhatIf reads the JSON result of az deployment what-if
+func readWhatIf(resp string) (whatIfResult, error) {
+	result := whatIfResult{}
+
+	if err := json.Unmarshal([]byte(resp), &result); err != nil {
+		return result, fmt.Errorf("unable to read the what-if result: %w", err)
+	}
+
+	if result.Error != nil {
+		return result, fmt.Errorf("what-if failed with %s: %s", result.Error.Code, result.Error.Message)
+	}
+
+	return result, nil
+}
+
+// summarizeWhatIf normalizes the what-if result into the runner agnostic summary of the changes planned by the
+// execution, with the property level changes of each resource
+func summarizeWhatIf(result whatIfResult) config.PlanSummary {
+	summary := config.PlanSummary{
+		ResourceChanges: []config.PlannedResourceChange{},
+		ResourceDrift:   []config.PlannedResourceChange{},
+		OutputChanges:   []config.PlannedOutputChange{},
+	}
+
+	for _, change := range result.Changes {
+		c := summarizeWhatIfChange(change)
+
+		switch c.Action {
+		case config.PlanActionCreate:
+			summary.Add++
+		case config.PlanActionUpdate:
+			summary.Change++
+		case config.PlanActionDelete:
+			summary.Destroy++
+		}
+
+		summary.ResourceChanges = append(summary.ResourceChanges, c)
+	}
+
+	return summary
+}
+
+// summarizeWhatIfChange normalizes the predicted change of a single resource
+func summarizeWhatIfChange(change whatIfChange) config.PlannedResourceChange {
+	provider, resourceType, name := parseResourceID(change.ResourceID)
+
+	c := config.PlannedResourceChange{
+		Address:          change.ResourceID,
+		Mode:             "managed",
+		Type:             resourceType,
+		Name:             name,
+		ProviderName:     provider,
+		AttributeChanges: []config.AttributeChange{},
+	}
+
+	switch strings.ToLower(change.ChangeType) {
+	case "create":
+		c.Action = config.PlanActionCreate
+	case "delete":
+		c.Action = config.PlanActionDelete
+	case "modify":
+		c.Action = config.PlanActionUpdate
+	case "deploy":
+		// the resource is redeployed, but what-if is unable to tell whether its properties change
+		c.Action = config.PlanActionUpdate
+		c.ActionReason = "deploy"
+	case "ignore":
+		// the resource exists but is not in the template, it is left as is by incremental deployments
+		c.Action = config.PlanActionNoOp
+		c.ActionReason = "ignore"
+	case "unsupported":
+		c.Action = config.PlanActionNoOp
+		c.ActionReason = change.UnsupportedReason
+	default:
+		c.Action = config.PlanActionNoOp
+	}
+
+	for _, delta := range change.Delta {
+		c.AttributeChanges = append(c.AttributeChanges, flattenPropertyChange("", delta)...)
+	}
+
+	sort.SliceStable(c.AttributeChanges, func(i, j int) bool {
+		return c.AttributeChanges[i].Path < c.AttributeChanges[j].Path
+	})
+
+	return c
+}
+
+// flattenPropertyChange returns the leaf changes of a property change, addressed by their path within the resource.
+// Changes without effect, e.g. of read-only properties, are omitted.
+func flattenPropertyChange(parent string, delta whatIfPropertyChange) []config.AttributeChange {
+	path := delta.Path
+	if parent != "" {
+		if whatIfArrayIndex.MatchString(path) {
+			path = fmt.Sprintf("%s[%s]", parent, path)
+		} else {
+			path = fmt.Sprintf("%s.%s", parent, path)
+		}
+	}
+
+	if len(delta.Children) > 0 {
+		changes := []config.AttributeChange{}
+		for _, child := range delta.Children {
+			changes = append(changes, flattenPropertyChange(path, child)...)
+		}
+		return changes
+	}
+
+	if strings.EqualFold(delta.PropertyChangeType, "NoEffect") {
+		return []config.AttributeChange{}
+	}
+
+	return []config.AttributeChange{{
+		Path:   path,
+		Before: delta.Before,
+		After:  delta.After,
+	}}
+}
+
+// parseResourceID returns the resource provider namespace, type and name of an ARM resource ID, e.g.
+// Microsoft.Storage, Microsoft.Storage/storageAccounts/blobServices and logs/default
+func parseResourceID(id string) (provider string, resourceType string, name string) {
+	segments := strings.Split(strings.Trim(id, "/"), "/")
+
+	providers := -1
+	for i, segment := range segments {
+		if strings.EqualFold(segment, "providers") {
+			providers = i
+		}
+	}
+
+	if providers < 0 {
+		// resource groups and subscriptions have no provider segment
+		if len(segments) < 2 {
+			return "", "", id
+		}
+
+		return "Microsoft.Resources", "Microsoft.Resources/" + segments[len(segments)-2], segments[len(segments)-1]
+	}
+
+	segments = segments[providers+1:]
+	if len(segments) == 0 {
+		return "", "", id
+	}
+
+	provider = segments[0]
+	types, names := []string{provider}, []string{}
+
+	for i := 1; i+1 < len(segments); i += 2 {
+		types = append(types, segments[i])
+		names = append(names, segments[i+1])
+	}
+
+	return provider, strings.Join(types, "/"), strings.Join(names, "/")
+}
@@ -0,0 +1,92 @@
+package plugins_arm
+
+import (
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+const whatIfResponse = `{
+  "status": "Succeeded",
+  "changes": [
+    {
+      "resourceId": "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/logs",
+      "changeType": "Modify",
+      "delta": [
+        {"path": "properties.supportsHttpsTrafficOnly", "propertyChangeType": "Modify", "before": false, "after": true},
+        {"path": "properties.provisioningState", "propertyChangeType": "NoEffect", "before": "Succeeded", "after": null},
+        {
+          "path": "properties.networkAcls.ipRules",
+          "propertyChangeType": "Array",
+          "children": [
+            {"path": "0", "propertyChangeType": "Delete", "before": {"value": "10.0.0.1"}}
+          ]
+        }
+      ]
+    },
+    {
+      "resourceId": "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/logs/blobServices/default",
+      "changeType": "Create"
+    },
+    {
+      "resourceId": "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/legacy",
+      "changeType": "Delete"
+    },
+    {
+      "resourceId": "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/manual",
+      "changeType": "Ignore"
+    },
+    {
+      "resourceId": "/subscriptions/0000/resourceGroups/rg",
+      "changeType": "NoChange"
+    }
+  ]
+}`
+
+func TestSummarizeWhatIf_ShouldNormalizeChanges(t *testing.T) {
+	result, err := readWhatIf(whatIfResponse)
+	require.NoError(t, err)
+
+	// act
+	summary := summarizeWhatIf(result)
+
+	// assert
+	require.Equal(t, 1, summary.Add)
+	require.Equal(t, 1, summary.Change)
+	require.Equal(t, 1, summary.Destroy)
+	require.True(t, summary.HasChanges())
+	require.Len(t, summary.ResourceChanges, 5)
+
+	modify := summary.ResourceChanges[0]
+	require.Equal(t, config.PlanActionUpdate, modify.Action)
+	require.Equal(t, "Microsoft.Storage", modify.ProviderName)
+	require.Equal(t, "Microsoft.Storage/storageAccounts", modify.Type)
+	require.Equal(t, "logs", modify.Name)
+	require.Equal(t, []config.AttributeChange{
+		{Path: "properties.networkAcls.ipRules[0]", Before: map[string]interface{}{"value": "10.0.0.1"}},
+		{Path: "properties.supportsHttpsTrafficOnly", Before: false, After: true},
+	}, modify.AttributeChanges)
+
+	create := summary.ResourceChanges[1]
+	require.Equal(t, config.PlanActionCreate, create.Action)
+	require.Equal(t, "Microsoft.Storage/storageAccounts/blobServices", create.Type)
+	require.Equal(t, "logs/default", create.Name)
+
+	require.Equal(t, config.PlanActionDelete, summary.ResourceChanges[2].Action)
+
+	ignore := summary.ResourceChanges[3]
+	require.Equal(t, config.PlanActionNoOp, ignore.Action)
+	require.Equal(t, "ignore", ignore.ActionReason)
+
+	group := summary.ResourceChanges[4]
+	require.Equal(t, config.PlanActionNoOp, group.Action)
+	require.Equal(t, "Microsoft.Resources/resourceGroups", group.Type)
+	require.Equal(t, "rg", group.Name)
+}
+
+func TestReadWhatIf_ShouldFailOnError(t *testing.T) {
+	_, err := readWhatIf(`{"status": "Failed", "error": {"code": "InvalidTemplate", "message": "The template is invalid."}}`)
+
+	require.EqualError(t, err, "what-if failed with InvalidTemplate: The template is invalid.")
+}
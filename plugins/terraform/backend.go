@@ -0,0 +1,443 @@
+package plugins_terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/optum/runiac/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TFBackendType represents a Terraform backend type
+type TFBackendType string
+
+const (
+	// S3Backend backend
+	S3Backend TFBackendType = "s3"
+	// Azure Storage Account backend
+	AzureStorageAccount TFBackendType = "azurerm"
+	// Google Cloud Storage backend
+	GCSBackend TFBackendType = "gcs"
+	// LocalBackend backend
+	LocalBackend TFBackendType = "local"
+	// HTTPBackend backend
+	HTTPBackend TFBackendType = "http"
+	// PostgresBackend backend
+	PostgresBackend TFBackendType = "pg"
+	// ConsulBackend backend
+	ConsulBackend TFBackendType = "consul"
+	// KubernetesBackend backend
+	KubernetesBackend TFBackendType = "kubernetes"
+	// RemoteBackend backend (Terraform Cloud/Enterprise)
+	RemoteBackend TFBackendType = "remote"
+	// UnknownBackend represents an unknown backend
+	UnknownBackend TFBackendType = "unknown"
+)
+
+func (b TFBackendType) String() string {
+	return string(b)
+}
+
+// TFBackendConvention describes how runiac configures a terraform backend type
+type TFBackendConvention struct {
+	// SupportsWorkspaces is false for backends that only support the default workspace.
+	// runiac will not select a per region workspace for these backends.
+	SupportsWorkspaces bool
+	// SingleWorkspace returns whether the backend configuration addresses a single workspace, e.g. a remote backend
+	// declaring workspaces { name = ... }. runiac will not select a per region workspace for these configurations.
+	SingleWorkspace func(config map[string]interface{}) bool
+	// Defaults returns the backend configuration used for any attribute not declared in backend.tf.
+	// Defaults should include the namespace, step and region so that each execution has its own state.
+	Defaults func(exec config.StepExecution) map[string]interface{}
+	// Sensitive are the attributes holding credentials. Their values are registered as secrets, so they are
+	// redacted from all logs, including the logged -backend-config arguments of terraform init.
+	Sensitive []string
+}
+
+// TFBackends is the registry of supported backend types. Adding a backend only requires registering its convention.
+var TFBackends = map[TFBackendType]TFBackendConvention{
+	S3Backend: {
+		SupportsWorkspaces: true,
+		Sensitive:          []string{"access_key", "secret_key", "token"},
+		Defaults: func(exec config.StepExecution) map[string]interface{} {
+			return map[string]interface{}{"key": stateKey(exec, "/") + ".tfstate"}
+		},
+	},
+	AzureStorageAccount: {
+		SupportsWorkspaces: true,
+		Sensitive:          []string{"access_key", "sas_token", "client_secret"},
+		Defaults: func(exec config.StepExecution) map[string]interface{} {
+			return map[string]interface{}{"key": stateKey(exec, "/") + ".tfstate"}
+		},
+	},
+	GCSBackend: {
+		// prefix is optional, steps declaring none keep their state at the bucket root, separated by workspace
+		SupportsWorkspaces: true,
+		Sensitive:          []string{"credentials", "access_token", "encryption_key"},
+	},
+	ConsulBackend: {
+		SupportsWorkspaces: true,
+		Sensitive:          []string{"access_token", "http_auth"},
+		Defaults: func(exec config.StepExecution) map[string]interface{} {
+			return map[string]interface{}{"path": stateKey(exec, "/")}
+		},
+	},
+	PostgresBackend: {
+		SupportsWorkspaces: true,
+		Sensitive:          []string{"conn_str"},
+		Defaults: func(exec config.StepExecution) map[string]interface{} {
+			// schema names must be valid unquoted postgres identifiers
+			return map[string]interface{}{"schema_name": sanitizeStateKey(stateKey(exec, "_"), "_")}
+		},
+	},
+	KubernetesBackend: {
+		SupportsWorkspaces: true,
+		Sensitive:          []string{"token", "password", "client_key"},
+		Defaults: func(exec config.StepExecution) map[string]interface{} {
+			// secret names must be valid DNS subdomain names
+			return map[string]interface{}{"secret_suffix": sanitizeStateKey(stateKey(exec, "-"), "-")}
+		},
+	},
+	RemoteBackend: {
+		SupportsWorkspaces: true,
+		Sensitive:          []string{"token"},
+		SingleWorkspace: func(config map[string]interface{}) bool {
+			// only workspaces { prefix = ... } maps workspace names to remote workspaces
+			workspaces, _ := config["workspaces"].(map[string]interface{})
+			_, prefixed := workspaces["prefix"]
+			return !prefixed
+		},
+		Defaults: func(exec config.StepExecution) map[string]interface{} {
+			// the region is included through the selected workspace, e.g. {prefix}{namespace}-{regionDeployType}-{region}
+			return map[string]interface{}{
+				"workspaces": map[string]interface{}{
+					"prefix": sanitizeStateKey(stateKeyPrefix(exec, "-"), "-") + "-",
+				},
+			}
+		},
+	},
+	HTTPBackend: {
+		// the http backend only supports the default workspace, the region must be interpolated into the address
+		SupportsWorkspaces: false,
+		Sensitive:          []string{"password"},
+	},
+	LocalBackend: {
+		SupportsWorkspaces: true,
+	},
+}
+
+// StringToBackendType converts a string to a ProviderType
+func StringToBackendType(s string) (TFBackendType, error) {
+	if _, exists := TFBackends[TFBackendType(s)]; !exists {
+		return UnknownBackend, errors.New("Invalid backend string")
+	}
+	return TFBackendType(s), nil
+}
+
+// stateKeyPrefix returns the namespace, track and step of the execution joined by sep
+func stateKeyPrefix(exec config.StepExecution, sep string) string {
+	parts := []string{}
+
+	if exec.Namespace != "" {
+		parts = append(parts, exec.Namespace)
+	}
+
+	return strings.Join(append(parts, exec.TrackName, exec.StepName), sep)
+}
+
+// stateKey returns the conventional state key of the execution, e.g. {namespace}/{track}/{step}/{regionDeployType}-{region}
+func stateKey(exec config.StepExecution, sep string) string {
+	return strings.Join([]string{stateKeyPrefix(exec, sep), fmt.Sprintf("%s-%s", exec.RegionDeployType, exec.Region)}, sep)
+}
+
+var invalidStateKeyChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sanitizeStateKey lower cases the key and replaces any run of characters other than letters and digits with sep
+func sanitizeStateKey(key string, sep string) string {
+	return strings.Trim(invalidStateKeyChars.ReplaceAllString(strings.ToLower(key), sep), sep)
+}
+
+// TerraformBackend is a structure that represents a terraform backend file
+type TerraformBackend struct {
+	Type       TFBackendType
+	Attributes map[string]interface{} // Attributes declared in the backend block, prior to interpolation
+	Config     map[string]interface{} // Interpolated configuration passed to terraform init as -backend-config
+}
+
+// SupportsWorkspaces returns whether runiac selects a per region workspace for the backend configuration
+func (b TerraformBackend) SupportsWorkspaces() bool {
+	convention := TFBackends[b.Type]
+
+	if convention.SingleWorkspace != nil && convention.SingleWorkspace(b.Config) {
+		return false
+	}
+
+	return convention.SupportsWorkspaces
+}
+
+// TFBackendParser is a function type that handles parsing a backend.tf file
+type TFBackendParser func(fs afero.Fs, log *logrus.Entry, file string) (backend TerraformBackend, err error)
+
+// ParseTFBackend parses the terraform backend block from a backend.tf or backend.tf.json file.
+// When the file does not exist or declares no backend, the local backend is used.
+func ParseTFBackend(fs afero.Fs, log *logrus.Entry, file string) (backend TerraformBackend, err error) {
+	backend.Type = LocalBackend
+	backend.Attributes = map[string]interface{}{}
+
+	b, err := afero.ReadFile(fs, file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Debugf("No backend file found at %s, using local backend", file)
+			return backend, nil
+		}
+		return backend, fmt.Errorf("unable to read backend file %s: %w", file, err)
+	}
+
+	var block *hcl.Block
+	var attrs hcl.Attributes
+	var nested map[string]hcl.Attributes
+	var diags hcl.Diagnostics
+
+	if strings.HasSuffix(file, ".json") {
+		block, attrs, nested, diags = parseJSONBackendBlock(b, file)
+	} else {
+		block, attrs, nested, diags = parseHCLBackendBlock(b, file)
+	}
+
+	if diags.HasErrors() {
+		return backend, fmt.Errorf("unable to parse backend file %s: %s", file, diags.Error())
+	}
+
+	if block == nil {
+		log.Debugf("No backend block declared in %s, using local backend", file)
+		return backend, nil
+	}
+
+	backend.Type, err = StringToBackendType(block.Labels[0])
+	if err != nil {
+		return backend, fmt.Errorf("unsupported backend type %q in %s", block.Labels[0], file)
+	}
+
+	for name, attr := range attrs {
+		val, err := backendAttributeValue(attr.Expr, b)
+		if err != nil {
+			return backend, fmt.Errorf("unable to parse backend attribute %s in %s: %w", name, file, err)
+		}
+		backend.Attributes[name] = val
+	}
+
+	for blockType, blockAttrs := range nested {
+		blockValues := map[string]interface{}{}
+		for name, attr := range blockAttrs {
+			val, err := backendAttributeValue(attr.Expr, b)
+			if err != nil {
+				return backend, fmt.Errorf("unable to parse backend attribute %s.%s in %s: %w", blockType, name, file, err)
+			}
+			blockValues[name] = val
+		}
+		backend.Attributes[blockType] = blockValues
+	}
+
+	return backend, nil
+}
+
+var backendFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "terraform"},
+	},
+}
+
+var terraformBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "backend", LabelNames: []string{"type"}},
+	},
+}
+
+// findBackendBlock returns the first backend block nested in a terraform block
+func findBackendBlock(body hcl.Body) (*hcl.Block, hcl.Diagnostics) {
+	content, _, diags := body.PartialContent(backendFileSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	for _, tfBlock := range content.Blocks {
+		tfContent, _, tfDiags := tfBlock.Body.PartialContent(terraformBlockSchema)
+		diags = append(diags, tfDiags...)
+		if tfDiags.HasErrors() {
+			return nil, diags
+		}
+
+		if len(tfContent.Blocks) > 0 {
+			return tfContent.Blocks[0], diags
+		}
+	}
+
+	return nil, diags
+}
+
+func parseHCLBackendBlock(src []byte, filename string) (*hcl.Block, hcl.Attributes, map[string]hcl.Attributes, hcl.Diagnostics) {
+	f, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, nil, nil, diags
+	}
+
+	block, diags := findBackendBlock(f.Body)
+	if block == nil || diags.HasErrors() {
+		return block, nil, nil, diags
+	}
+
+	attrs := hcl.Attributes{}
+	for name, attr := range block.Body.(*hclsyntax.Body).Attributes {
+		attrs[name] = attr.AsHCLAttribute()
+	}
+
+	// nested blocks (e.g. workspaces) are returned as object attributes, matching the json syntax
+	nested := map[string]hcl.Attributes{}
+	for _, b := range block.Body.(*hclsyntax.Body).Blocks {
+		nested[b.Type] = hcl.Attributes{}
+		for name, attr := range b.Body.Attributes {
+			nested[b.Type][name] = attr.AsHCLAttribute()
+		}
+	}
+
+	return block, attrs, nested, diags
+}
+
+func parseJSONBackendBlock(src []byte, filename string) (*hcl.Block, hcl.Attributes, map[string]hcl.Attributes, hcl.Diagnostics) {
+	f, diags := hcljson.Parse(src, filename)
+	if diags.HasErrors() {
+		return nil, nil, nil, diags
+	}
+
+	block, diags := findBackendBlock(f.Body)
+	if block == nil || diags.HasErrors() {
+		return block, nil, nil, diags
+	}
+
+	// json cannot distinguish nested blocks from object attributes, both are returned as attributes
+	attrs, attrDiags := block.Body.JustAttributes()
+	return block, attrs, nil, append(diags, attrDiags...)
+}
+
+// backendAttributeValue converts a backend attribute expression into a go value. Backend blocks cannot reference
+// variables in terraform, however runiac interpolates ${var.runiac_*} references itself, so template expressions
+// that cannot be evaluated statically are returned as their template string.
+func backendAttributeValue(expr hcl.Expression, src []byte) (interface{}, error) {
+	val, diags := expr.Value(nil)
+	if !diags.HasErrors() {
+		return ctyToGo(val)
+	}
+
+	switch e := expr.(type) {
+	case *hclsyntax.TemplateExpr:
+		var sb strings.Builder
+		for _, part := range e.Parts {
+			if lit, ok := part.(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+				sb.WriteString(lit.Val.AsString())
+			} else {
+				sb.WriteString("${" + string(part.Range().SliceBytes(src)) + "}")
+			}
+		}
+		return sb.String(), nil
+	case *hclsyntax.TemplateWrapExpr:
+		return "${" + string(e.Wrapped.Range().SliceBytes(src)) + "}", nil
+	case *hclsyntax.ScopeTraversalExpr:
+		return "${" + string(e.Range().SliceBytes(src)) + "}", nil
+	}
+
+	return nil, errors.New(diags.Error())
+}
+
+func ctyToGo(val cty.Value) (interface{}, error) {
+	if val.IsNull() {
+		return nil, nil
+	}
+
+	switch val.Type() {
+	case cty.String:
+		return val.AsString(), nil
+	case cty.Bool:
+		return val.True(), nil
+	case cty.Number:
+		if i, acc := val.AsBigFloat().Int64(); acc == big.Exact {
+			return i, nil
+		}
+		f, _ := val.AsBigFloat().Float64()
+		return f, nil
+	}
+
+	// lists, maps and objects round trip through json
+	j, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	err = json.Unmarshal(j, &v)
+	return v, err
+}
+
+// writeBackendConfigFile renders block-valued backend configuration (e.g. the remote backend's workspaces block),
+// which cannot be passed as -backend-config=key=value, into a backend configuration file and returns its path.
+func writeBackendConfigFile(fs afero.Fs, blocks map[string]map[string]interface{}) (string, error) {
+	f := hclwrite.NewEmptyFile()
+
+	names := make([]string, 0, len(blocks))
+	for name := range blocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		body := f.Body().AppendNewBlock(name, nil).Body()
+
+		keys := make([]string, 0, len(blocks[name]))
+		for k := range blocks[name] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			body.SetAttributeValue(k, goToCty(blocks[name][k]))
+		}
+	}
+
+	tmp, err := afero.TempFile(fs, "", "runiac-backend-*.hcl")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+
+	if _, err = tmp.Write(f.Bytes()); err != nil {
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
+
+func goToCty(v interface{}) cty.Value {
+	switch val := v.(type) {
+	case string:
+		return cty.StringVal(val)
+	case bool:
+		return cty.BoolVal(val)
+	case int64:
+		return cty.NumberIntVal(val)
+	case float64:
+		return cty.NumberFloatVal(val)
+	}
+
+	return cty.StringVal(fmt.Sprintf("%v", v))
+}
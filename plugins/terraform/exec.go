@@ -0,0 +1,103 @@
+package plugins_terraform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/shell"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+)
+
+// varCommands are the terraform subcommands that accept the -var and -var-file arguments of a deployment
+var varCommands = []string{"plan", "apply", "destroy", "refresh", "import", "console"}
+
+// PrepareStepCommand initializes terraform for the execution as ExecuteStep would, without planning or applying
+func (stepper TerraformStepper) PrepareStepCommand(exec config.StepExecution) (command config.StepCommand, err error) {
+	tfOptions, backend, err := initTerraformInDir(exec)
+
+	if err != nil {
+		return
+	}
+
+	command.Binary = tfOptions.TerraformBinary
+	command.Dir = exec.Dir
+	command.Env = GetTerraformCommandEnv(exec, tfOptions, backend)
+
+	return
+}
+
+// ReadStepOutputs reads the outputs of the execution's last deployment from its state
+func (stepper TerraformStepper) ReadStepOutputs(exec config.StepExecution) (output config.StepOutput) {
+	output.RegionDeployType = exec.RegionDeployType
+	output.Region = exec.Region
+	output.StepName = exec.StepName
+	output.Status = config.Fail // assume failure
+
+	tfOptions, _, err := initTerraformInDir(exec)
+
+	if err != nil {
+		output.Err = err
+		return
+	}
+
+	tfOptions.Logger = tfOptions.Logger.WithField("terraform", "output")
+
+	if output.Err = readTerraformOutputs(tfOptions, &output); output.Err != nil {
+		tfOptions.Logger.WithError(output.Err).Error("Error running terraform output")
+		return
+	}
+
+	output.Status = config.Success
+
+	return
+}
+
+// GetTerraformCommandEnv returns the environment that makes terraform commands run within the execution's directory
+// behave as they do during a deployment: step parameters and previous step outputs as TF_VAR_ variables, the selected
+// workspace, the backend configuration for a re-init, and runiac's -var and -var-file arguments for the subcommands that
+// accept them. Nested backend blocks are only passed to the initial init, since their configuration file is temporary.
+func GetTerraformCommandEnv(exec config.StepExecution, options *terraform.Options, backend TerraformBackend) map[string]string {
+	env := map[string]string{}
+
+	for k, v := range options.EnvVars {
+		env[k] = v
+	}
+
+	for k, v := range GetTerraformEnvVars(exec) {
+		env[fmt.Sprintf("TF_VAR_%s", k)] = v
+	}
+
+	if backend.SupportsWorkspaces() {
+		env["TF_WORKSPACE"] = terraformWorkspace(exec)
+	}
+
+	if len(options.BackendConfig) > 0 {
+		env["TF_CLI_ARGS_init"] = shell.QuoteArgs(sortedArgs(terraform.FormatTerraformBackendConfigAsArgs, options.BackendConfig))
+	}
+
+	varArgs := sortedArgs(terraform.FormatTerraformVarsAsArgs, GetTerraformCLIVars(exec))
+	varArgs = append(varArgs, terraform.FormatTerraformArgs("-var-file", GetTerraformVarFiles(exec))...)
+
+	for _, command := range varCommands {
+		env[fmt.Sprintf("TF_CLI_ARGS_%s", command)] = shell.QuoteArgs(varArgs)
+	}
+
+	return env
+}
+
+// sortedArgs formats the variables as arguments ordered by variable name, so the environment is stable across runs
+func sortedArgs(format func(map[string]interface{}) []string, vars map[string]interface{}) []string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := []string{}
+	for _, k := range keys {
+		args = append(args, format(map[string]interface{}{k: vars[k]})...)
+	}
+
+	return args
+}
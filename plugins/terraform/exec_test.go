@@ -0,0 +1,61 @@
+package plugins_terraform
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTerraformCommandEnv_ShouldReproduceDeploymentEnvironment(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, filepath.Join("/step/vars", "common.tfvars"), []byte(""), 0644)
+
+	exec := config.StepExecution{
+		Fs:                 fs,
+		Dir:                "/step",
+		Namespace:          "ns",
+		Environment:        "nonprod",
+		AccountID:          "account",
+		Region:             "us-east-1",
+		RegionDeployType:   config.RegionalRegionDeployType,
+		AppVersion:         "v1",
+		OptionalStepParams: map[string]string{"network-vpc_id": "vpc-123"},
+	}
+
+	options := &terraform.Options{
+		EnvVars:       map[string]string{"TF_PLUGIN_CACHE_DIR": "/cache"},
+		BackendConfig: map[string]interface{}{"key": "ns/step.tfstate", "bucket": "it's-a-bucket"},
+	}
+
+	env := GetTerraformCommandEnv(exec, options, TerraformBackend{Type: S3Backend})
+
+	require.Equal(t, "/cache", env["TF_PLUGIN_CACHE_DIR"])
+	require.Equal(t, "vpc-123", env["TF_VAR_network-vpc_id"])
+	require.Equal(t, "v1", env["TF_VAR_runiac_app_version"])
+	require.Equal(t, "ns-regional-us-east-1", env["TF_WORKSPACE"])
+	require.Equal(t, `'-backend-config=bucket=it'\''s-a-bucket' '-backend-config=key=ns/step.tfstate'`, env["TF_CLI_ARGS_init"])
+
+	varArgs := `'-var' 'runiac_account_id=account' '-var' 'runiac_environment=nonprod' '-var' 'runiac_region=us-east-1' '-var-file' 'vars/common.tfvars'`
+	for _, command := range varCommands {
+		require.Equal(t, varArgs, env["TF_CLI_ARGS_"+command], command)
+	}
+
+	require.NotContains(t, env, "TF_CLI_ARGS_output", "Commands that do not accept variables should not receive them")
+}
+
+func TestGetTerraformCommandEnv_ShouldNotSelectWorkspaceForBackendsWithoutWorkspaces(t *testing.T) {
+	exec := config.StepExecution{
+		Fs:                 afero.NewMemMapFs(),
+		Dir:                "/step",
+		OptionalStepParams: map[string]string{},
+	}
+
+	env := GetTerraformCommandEnv(exec, &terraform.Options{EnvVars: map[string]string{}}, TerraformBackend{Type: TFBackendType("unknown")})
+
+	require.NotContains(t, env, "TF_WORKSPACE")
+	require.NotContains(t, env, "TF_CLI_ARGS_init")
+}
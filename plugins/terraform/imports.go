@@ -0,0 +1,111 @@
+package plugins_terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// ImportsFile is the optional file within a step's directory, or its regional directory, declaring existing resources
+// to import into the execution's state
+const ImportsFile = "imports.yml"
+
+// ResourceImport is an existing resource to import into the state at the address. The address and ID may reference
+// runiac variables, e.g. ${var.runiac_region}, as the backend configuration does.
+type ResourceImport struct {
+	Address string `yaml:"address"`
+	ID      string `yaml:"id"`
+}
+
+type importsConfig struct {
+	Imports []ResourceImport `yaml:"imports"`
+}
+
+// GetResourceImports reads the execution's imports file and interpolates the runiac variables of the execution
+func GetResourceImports(exec config.StepExecution) ([]ResourceImport, error) {
+	file := filepath.Join(exec.Dir, ImportsFile)
+
+	b, err := afero.ReadFile(exec.Fs, file)
+	if os.IsNotExist(err) {
+		return []ResourceImport{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", file, err)
+	}
+
+	conf := importsConfig{}
+	if err = yaml.UnmarshalStrict(b, &conf); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", file, err)
+	}
+
+	imports := []ResourceImport{}
+
+	for i, imp := range conf.Imports {
+		imp.Address = interpolateString(exec, imp.Address)
+		imp.ID = interpolateString(exec, imp.ID)
+
+		if imp.Address == "" || imp.ID == "" {
+			return nil, fmt.Errorf("import %d in %s requires an address and an id", i+1, file)
+		}
+
+		if strings.Contains(imp.Address, "${") || strings.Contains(imp.ID, "${") {
+			return nil, fmt.Errorf("import of %s in %s references an unknown variable, only runiac variables are supported", imp.Address, file)
+		}
+
+		imports = append(imports, imp)
+	}
+
+	return imports, nil
+}
+
+// importResources imports the execution's declared resources that are not yet in its state, so importing is
+// idempotent. Imports modify the state, so they are skipped during a dry run.
+func importResources(exec config.StepExecution, options *terraform.Options, phase func(options *terraform.Options, phase string, action func(attempt int) (string, error)) (string, error)) error {
+	imports, err := GetResourceImports(exec)
+	if err != nil || len(imports) == 0 {
+		return err
+	}
+
+	importOptions := *options
+	importOptions.Logger = options.Logger.WithField("terraform", "import")
+
+	if exec.DryRun {
+		importOptions.Logger.Warnf("Skipping the import of %d resources, this is a dry run. The plan will show them as created.", len(imports))
+		return nil
+	}
+
+	var existing []string
+
+	_, err = retryPhase(&importOptions, "state", func(attempt int) (string, error) {
+		existing, err = terraformer.StateList(&importOptions)
+		return "", err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, imp := range imports {
+		if contains(existing, imp.Address) {
+			importOptions.Logger.Debugf("Skipping the import of %s, it is already in the state", imp.Address)
+			continue
+		}
+
+		importOptions.Logger.Infof("Importing %s", imp.Address)
+
+		address, id := imp.Address, imp.ID
+
+		if _, err = phase(&importOptions, "import", func(attempt int) (string, error) {
+			return terraformer.Import(&importOptions, address, id)
+		}); err != nil {
+			return fmt.Errorf("unable to import %s: %w", address, err)
+		}
+	}
+
+	return nil
+}
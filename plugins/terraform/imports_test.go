@@ -0,0 +1,143 @@
+package plugins_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// importSpy serves the addresses in the state and records the imports
+type importSpy struct {
+	terraform.Terraform
+	existing []string
+	imported *[]string
+}
+
+func (s importSpy) StateList(options *terraform.Options) ([]string, error) {
+	return s.existing, nil
+}
+
+func (s importSpy) Import(options *terraform.Options, address string, id string) (string, error) {
+	*s.imported = append(*s.imported, fmt.Sprintf("%s=%s", address, id))
+	return "", nil
+}
+
+func stubImportExecution(imports string) config.StepExecution {
+	fs := afero.NewMemMapFs()
+
+	if imports != "" {
+		_ = afero.WriteFile(fs, "/step/imports.yml", []byte(imports), 0644)
+	}
+
+	return config.StepExecution{
+		Fs:          fs,
+		Logger:      logger,
+		Dir:         "/step",
+		Region:      "us-east-1",
+		AccountID:   "123456789012",
+		Environment: "nonprod",
+		Namespace:   "ns",
+	}
+}
+
+func TestGetResourceImports(t *testing.T) {
+	tests := map[string]struct {
+		imports  string
+		expected []ResourceImport
+		err      bool
+	}{
+		"no imports file": {expected: []ResourceImport{}},
+		"interpolates runiac variables": {
+			imports: `
+imports:
+  - address: aws_s3_bucket.logs
+    id: ${var.runiac_account_id}-logs-${var.runiac_region}
+  - address: module.dns["${var.runiac_environment}"].aws_route53_zone.main
+    id: Z123
+`,
+			expected: []ResourceImport{
+				{Address: "aws_s3_bucket.logs", ID: "123456789012-logs-us-east-1"},
+				{Address: `module.dns["nonprod"].aws_route53_zone.main`, ID: "Z123"},
+			},
+		},
+		"missing id": {
+			imports: `
+imports:
+  - address: aws_s3_bucket.logs
+`,
+			err: true,
+		},
+		"unknown variable": {
+			imports: `
+imports:
+  - address: aws_s3_bucket.logs
+    id: ${var.bucket_name}
+`,
+			err: true,
+		},
+		"unknown field": {
+			imports: `
+imports:
+  - address: aws_s3_bucket.logs
+    identifier: logs
+`,
+			err: true,
+		},
+	}
+
+	for name, test := range tests {
+		imports, err := GetResourceImports(stubImportExecution(test.imports))
+		if test.err {
+			require.Error(t, err, name)
+			continue
+		}
+
+		require.NoError(t, err, name)
+		require.Equal(t, test.expected, imports, name)
+	}
+}
+
+func TestImportResources(t *testing.T) {
+	imports := `
+imports:
+  - address: aws_s3_bucket.logs
+    id: logs-${var.runiac_region}
+  - address: aws_vpc.main
+    id: vpc-123
+`
+
+	tests := map[string]struct {
+		existing []string
+		dryRun   bool
+		expected []string
+	}{
+		"imports resources":          {existing: []string{}, expected: []string{"aws_s3_bucket.logs=logs-us-east-1", "aws_vpc.main=vpc-123"}},
+		"skips resources in state":   {existing: []string{"aws_vpc.main"}, expected: []string{"aws_s3_bucket.logs=logs-us-east-1"}},
+		"skips imports of a dry run": {existing: []string{}, dryRun: true, expected: []string{}},
+	}
+
+	original := terraformer
+	defer func() { terraformer = original }()
+
+	for name, test := range tests {
+		imported := []string{}
+		terraformer = importSpy{existing: test.existing, imported: &imported}
+
+		exec := stubImportExecution(imports)
+		exec.DryRun = test.dryRun
+
+		phases := []string{}
+		err := importResources(exec, &terraform.Options{Logger: logger}, func(options *terraform.Options, phase string, action func(attempt int) (string, error)) (string, error) {
+			phases = append(phases, phase)
+			return action(0)
+		})
+
+		require.NoError(t, err, name)
+		require.Equal(t, test.expected, imported, name)
+		require.Len(t, phases, len(test.expected), name)
+	}
+}
@@ -0,0 +1,57 @@
+package plugins_terraform
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/optum/runiac/pkg/config"
+)
+
+// keepLocalState keeps the local state of an execution in its step's source directory rather than its working
+// directory, so the state outlives the run's scratch area. Relative state paths declared in backend.tf are resolved
+// against the source directory and the default state locations are linked to it.
+func keepLocalState(exec config.StepExecution, backend *TerraformBackend) error {
+	if exec.SourceDir == "" || exec.SourceDir == exec.Dir {
+		return nil
+	}
+
+	sourceDir, err := filepath.Abs(exec.SourceDir)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range []string{"path", "workspace_dir"} {
+		if p, ok := backend.Config[key].(string); ok && p != "" && !filepath.IsAbs(p) {
+			backend.Config[key] = filepath.Join(sourceDir, p)
+		}
+	}
+
+	links := []string{}
+
+	if _, declared := backend.Config["path"]; !declared {
+		links = append(links, "terraform.tfstate", "terraform.tfstate.backup")
+	}
+
+	if _, declared := backend.Config["workspace_dir"]; !declared {
+		// terraform creates the state of new workspaces within the directory, so it must exist for the link to resolve
+		if err = os.MkdirAll(filepath.Join(sourceDir, "terraform.tfstate.d"), 0755); err != nil {
+			return err
+		}
+
+		links = append(links, "terraform.tfstate.d")
+	}
+
+	for _, name := range links {
+		link := filepath.Join(exec.Dir, name)
+
+		if _, err = os.Lstat(link); err == nil {
+			continue
+		}
+
+		if err = os.Symlink(filepath.Join(sourceDir, name), link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package plugins_terraform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepLocalState_ShouldKeepStateInSourceDir(t *testing.T) {
+	sourceDir, err := ioutil.TempDir("", "runiac-source-")
+	require.NoError(t, err)
+	defer os.RemoveAll(sourceDir)
+
+	workDir, err := ioutil.TempDir("", "runiac-work-")
+	require.NoError(t, err)
+	defer os.RemoveAll(workDir)
+
+	exec := config.StepExecution{Dir: workDir, SourceDir: sourceDir}
+
+	// act
+	backend := TerraformBackend{Type: LocalBackend, Config: map[string]interface{}{}}
+	require.NoError(t, keepLocalState(exec, &backend))
+
+	declared := TerraformBackend{Type: LocalBackend, Config: map[string]interface{}{"path": "state/main.tfstate"}}
+	require.NoError(t, keepLocalState(exec, &declared))
+
+	// assert
+	target, err := os.Readlink(filepath.Join(workDir, "terraform.tfstate.d"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(sourceDir, "terraform.tfstate.d"), target)
+
+	info, err := os.Stat(filepath.Join(workDir, "terraform.tfstate.d"))
+	require.NoError(t, err)
+	require.True(t, info.IsDir(), "workspaces should resolve to the source directory")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "terraform.tfstate"), []byte("{}"), 0644))
+	_, err = os.Stat(filepath.Join(sourceDir, "terraform.tfstate"))
+	require.NoError(t, err, "the default state should be written to the source directory")
+
+	require.Equal(t, filepath.Join(sourceDir, "state/main.tfstate"), declared.Config["path"])
+}
+
+func TestKeepLocalState_ShouldIgnoreInPlaceExecutions(t *testing.T) {
+	backend := TerraformBackend{Type: LocalBackend, Config: map[string]interface{}{"path": "main.tfstate"}}
+
+	require.NoError(t, keepLocalState(config.StepExecution{Dir: "step", SourceDir: "step"}, &backend))
+	require.Equal(t, "main.tfstate", backend.Config["path"])
+}
@@ -20,10 +20,25 @@ func GetCommonOptions(options *Options, args ...string) (*Options, []string) {
 			options.EnvVars = map[string]string{}
 		}
 		options.EnvVars["TF_PLUGIN_CACHE_DIR"] = options.PluginCacheDir
+
+		// terraform 1.4+ otherwise bypasses the cache for steps without a dependency lock file
+		if options.PluginCacheMayBreakLock {
+			options.EnvVars["TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE"] = "true"
+		}
+	}
+
+	if options.Flavor == "" {
+		options.Flavor = TerraformFlavor
 	}
 
 	if options.TerraformBinary == "" {
-		options.TerraformBinary = "terraform"
+		options.TerraformBinary = options.Flavor.Binary()
+	}
+
+	for _, arg := range flavors[options.Flavor].CommonArgs {
+		if len(args) > 0 && !collections.ListContains(args, arg) {
+			args = append(args, arg)
+		}
 	}
 
 	return options, args
@@ -33,6 +48,43 @@ func GetCommonOptions(options *Options, args ...string) (*Options, []string) {
 func RunTerraformCommand(streamOutput bool, additionalOptions *Options, additionalArgs ...string) (string, error) {
 	options, args := GetCommonOptions(additionalOptions, additionalArgs...)
 
+	return RunTerraformCommandWithoutCommonArgs(streamOutput, options, args...)
+}
+
+// InitializedArgs appends the flavor's arguments for commands run on an initialized working directory
+func InitializedArgs(options *Options, args ...string) []string {
+	return append(args, flavors[options.Flavor].InitializedArgs...)
+}
+
+// RunTerraformCommandAndGetStdout runs terraform with the given arguments and options and returns only stdout.
+// This is used for commands with json output, since wrappers such as terragrunt log to stderr.
+func RunTerraformCommandAndGetStdout(additionalOptions *Options, additionalArgs ...string) (string, error) {
+	options, args := GetCommonOptions(additionalOptions, additionalArgs...)
+
+	env := make(map[string]string, len(options.EnvVars))
+	for k, v := range options.EnvVars {
+		env[k] = v
+	}
+	for k, v := range flavors[options.Flavor].StdoutEnvVars {
+		env[k] = v
+	}
+
+	cmd := shell.Command{
+		Command:           options.TerraformBinary,
+		Args:              args,
+		WorkingDir:        options.TerraformDir,
+		Env:               env,
+		OutputMaxLineSize: options.OutputMaxLineSize,
+		NonInteractive:    true,
+		SensitiveArgs:     false,
+		Logger:            options.Logger,
+	}
+
+	return shell.RunShellCommandAndGetStdout(cmd)
+}
+
+// RunTerraformCommandWithoutCommonArgs runs terraform with exactly the given arguments and return stdout/stderr.
+func RunTerraformCommandWithoutCommonArgs(streamOutput bool, options *Options, args ...string) (string, error) {
 	cmd := shell.Command{
 		Command:           options.TerraformBinary,
 		Args:              args,
@@ -44,6 +96,16 @@ func RunTerraformCommand(streamOutput bool, additionalOptions *Options, addition
 		Logger:            options.Logger,
 	}
 
+	if options.JSONUI {
+		cmd.OutputHandler = func(line string) bool {
+			event, ok := ParseUIEvent(line)
+			if ok {
+				LogUIEvent(options.Logger, event)
+			}
+			return ok
+		}
+	}
+
 	options.Logger.Debugf("Executing Command with following Env Vars set: %s", KeysStringString(cmd.Env))
 
 	if streamOutput {
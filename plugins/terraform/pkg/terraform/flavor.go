@@ -0,0 +1,102 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Flavor is a terraform compatible CLI used to execute steps
+type Flavor string
+
+const (
+	// TerraformFlavor executes steps with terraform
+	TerraformFlavor Flavor = "terraform"
+	// OpenTofuFlavor executes steps with OpenTofu
+	OpenTofuFlavor Flavor = "tofu"
+	// TerragruntFlavor executes steps with terragrunt, which wraps terraform
+	TerragruntFlavor Flavor = "terragrunt"
+)
+
+// flavorConvention describes how a flavor's CLI differs from terraform
+type flavorConvention struct {
+	Binary            string            // Default binary name on PATH
+	VersionArgs       []string          // Arguments to print the CLI version
+	CommonArgs        []string          // Arguments appended to every command
+	InitializedArgs   []string          // Arguments appended to commands run after init, e.g. workspace, show and output
+	StdoutEnvVars     map[string]string // Environment variables of commands whose stdout is parsed, e.g. as json
+	WorkspaceOrCreate bool              // Whether workspace select creates a missing workspace with -or-create
+	Banner            string            // Prefix of the first line of the version output, used to detect the flavor
+}
+
+var flavors = map[Flavor]flavorConvention{
+	TerraformFlavor: {
+		Binary:      "terraform",
+		VersionArgs: []string{"version"},
+		Banner:      "Terraform",
+	},
+	OpenTofuFlavor: {
+		Binary:      "tofu",
+		VersionArgs: []string{"version"},
+		// every OpenTofu release supports -or-create, terraform only since 1.4
+		WorkspaceOrCreate: true,
+		Banner:            "OpenTofu",
+	},
+	TerragruntFlavor: {
+		Binary:      "terragrunt",
+		VersionArgs: []string{"--version"},
+		// terragrunt passes unknown arguments through to terraform, this disables its own prompts
+		CommonArgs: []string{"--terragrunt-non-interactive"},
+		// the step is initialized with runiac's backend configuration, terragrunt's auto init would run without it
+		InitializedArgs: []string{"--terragrunt-no-auto-init"},
+		// terragrunt otherwise prefixes terraform's stdout with its own log format, breaking json output
+		StdoutEnvVars: map[string]string{"TERRAGRUNT_FORWARD_TF_STDOUT": "true"},
+		Banner:        "terragrunt",
+	},
+}
+
+var versionRegex = regexp.MustCompile(`v?(\d+\.\d+\.\d+\S*)`)
+
+// ParseFlavor converts a string to a Flavor, an empty string is the terraform flavor
+func ParseFlavor(s string) (Flavor, error) {
+	if s == "" {
+		return TerraformFlavor, nil
+	}
+
+	f := Flavor(strings.ToLower(s))
+	if _, ok := flavors[f]; !ok {
+		return TerraformFlavor, fmt.Errorf("invalid terraform flavor %q, must be one of terraform, tofu or terragrunt", s)
+	}
+
+	return f, nil
+}
+
+// Binary returns the flavor's default binary name
+func (f Flavor) Binary() string {
+	if c, ok := flavors[f]; ok {
+		return c.Binary
+	}
+	return flavors[TerraformFlavor].Binary
+}
+
+// ParseVersionOutput detects the flavor and version from the output of the version command
+func ParseVersionOutput(out string) (flavor Flavor, version string, err error) {
+	firstLine := strings.TrimSpace(strings.SplitN(strings.TrimSpace(out), "\n", 2)[0])
+
+	for f, c := range flavors {
+		if strings.HasPrefix(firstLine, c.Banner) {
+			flavor = f
+		}
+	}
+
+	if flavor == "" {
+		return flavor, "", fmt.Errorf("unable to detect flavor from version output %q", firstLine)
+	}
+
+	match := versionRegex.FindStringSubmatch(firstLine)
+	if match == nil {
+		return flavor, "", fmt.Errorf("unable to detect version from version output %q", firstLine)
+	}
+
+	return flavor, match[1], nil
+}
@@ -0,0 +1,249 @@
+package terraform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBinary writes a shell script that records its arguments and mimics the version and output commands of a flavor
+func fakeBinary(t *testing.T, name string, versionOutput string) (binary string, argsLog string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries require a posix shell")
+	}
+
+	dir, err := ioutil.TempDir("", "runiac-flavor")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	binary = filepath.Join(dir, name)
+	argsLog = filepath.Join(dir, "args.log")
+
+	script := `#!/bin/sh
+echo "$@" >> "` + argsLog + `"
+case "$1" in
+  version|--version) echo "` + versionOutput + `" ;;
+  output)
+    echo "[INFO] noise written to stderr" >&2
+    # newer terragrunt versions prefix terraform's stdout with their log format unless it is forwarded
+    if [ "$TERRAGRUNT_FORWARD_TF_STDOUT" != "true" ]; then printf "STDOUT terraform: "; fi
+    echo '{"name":{"sensitive":false,"type":"string","value":"runiac"}}' ;;
+esac
+`
+	require.NoError(t, ioutil.WriteFile(binary, []byte(script), 0755))
+
+	return
+}
+
+func readArgsLog(t *testing.T, argsLog string) []string {
+	b, err := ioutil.ReadFile(argsLog)
+	require.NoError(t, err)
+
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+func TestParseFlavor(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected Flavor
+		err      bool
+	}{
+		"empty defaults to terraform":    {input: "", expected: TerraformFlavor},
+		"terraform":                      {input: "terraform", expected: TerraformFlavor},
+		"tofu":                           {input: "tofu", expected: OpenTofuFlavor},
+		"terragrunt is case insensitive": {input: "Terragrunt", expected: TerragruntFlavor},
+		"unknown":                        {input: "pulumi", err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			flavor, err := ParseFlavor(test.input)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, flavor)
+		})
+	}
+}
+
+func TestParseVersionOutput(t *testing.T) {
+	tests := map[string]struct {
+		output  string
+		flavor  Flavor
+		version string
+	}{
+		"terraform":  {output: "Terraform v1.3.9\non linux_amd64", flavor: TerraformFlavor, version: "1.3.9"},
+		"tofu":       {output: "OpenTofu v1.6.0-beta1\non linux_amd64", flavor: OpenTofuFlavor, version: "1.6.0-beta1"},
+		"terragrunt": {output: "terragrunt version v0.50.1\n", flavor: TerragruntFlavor, version: "0.50.1"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			flavor, version, err := ParseVersionOutput(test.output)
+			require.NoError(t, err)
+			require.Equal(t, test.flavor, flavor)
+			require.Equal(t, test.version, version)
+		})
+	}
+
+	_, _, err := ParseVersionOutput("Pulumi v3.0.0")
+	require.Error(t, err)
+}
+
+func TestDetectVersion_ShouldDetectFlavorFromFakeBinary(t *testing.T) {
+	tests := map[string]struct {
+		flavor        Flavor
+		versionOutput string
+		expectedArgs  string
+	}{
+		"terraform":  {flavor: TerraformFlavor, versionOutput: "Terraform v1.3.9", expectedArgs: "version"},
+		"tofu":       {flavor: OpenTofuFlavor, versionOutput: "OpenTofu v1.6.0", expectedArgs: "version"},
+		"terragrunt": {flavor: TerragruntFlavor, versionOutput: "terragrunt version v0.50.1", expectedArgs: "--version"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			binary, argsLog := fakeBinary(t, test.flavor.Binary(), test.versionOutput)
+
+			options := &Options{
+				TerraformBinary: binary,
+				Flavor:          test.flavor,
+				Logger:          tfOptions.Logger,
+			}
+
+			flavor, _, err := DetectVersion(options)
+			require.NoError(t, err)
+			require.Equal(t, test.flavor, flavor)
+			require.Equal(t, []string{test.expectedArgs}, readArgsLog(t, argsLog))
+		})
+	}
+}
+
+func TestOutputAllWithMeta_ShouldIgnoreTerragruntStderr(t *testing.T) {
+	binary, argsLog := fakeBinary(t, "terragrunt", "terragrunt version v0.50.1")
+
+	options := &Options{
+		TerraformBinary: binary,
+		Flavor:          TerragruntFlavor,
+		Logger:          tfOptions.Logger,
+	}
+
+	outputs, err := OutputAllWithMeta(options)
+	require.NoError(t, err)
+	require.Equal(t, "runiac", outputs["name"].Value)
+
+	require.Equal(t, []string{"output -no-color -json --terragrunt-no-auto-init --terragrunt-non-interactive"}, readArgsLog(t, argsLog))
+}
+
+func TestWorkspaceSelect_ShouldFollowFlavorConventions(t *testing.T) {
+	tests := map[string]struct {
+		flavor       Flavor
+		expectedArgs string
+	}{
+		"terraform":  {flavor: TerraformFlavor, expectedArgs: "workspace select dev"},
+		"tofu":       {flavor: OpenTofuFlavor, expectedArgs: "workspace select -or-create dev"},
+		"terragrunt": {flavor: TerragruntFlavor, expectedArgs: "workspace select dev --terragrunt-no-auto-init --terragrunt-non-interactive"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			binary, argsLog := fakeBinary(t, test.flavor.Binary(), "")
+
+			options := &Options{
+				TerraformBinary: binary,
+				Flavor:          test.flavor,
+				Logger:          tfOptions.Logger,
+			}
+
+			_, err := WorkspaceSelect(options, "dev")
+			require.NoError(t, err)
+			require.Equal(t, []string{test.expectedArgs}, readArgsLog(t, argsLog))
+		})
+	}
+}
+
+func TestGetCommonOptions_ShouldDefaultBinaryByFlavor(t *testing.T) {
+	options, args := GetCommonOptions(&Options{Flavor: OpenTofuFlavor}, "plan")
+	require.Equal(t, "tofu", options.TerraformBinary)
+	require.Equal(t, []string{"plan"}, args)
+
+	options, _ = GetCommonOptions(&Options{})
+	require.Equal(t, "terraform", options.TerraformBinary)
+	require.Equal(t, TerraformFlavor, options.Flavor)
+}
+
+func TestGetCommonOptions_ShouldOnlyBreakDependencyLockFileWhenEnabled(t *testing.T) {
+	options, _ := GetCommonOptions(&Options{PluginCacheDir: "/cache"}, "init")
+	require.Equal(t, "/cache", options.EnvVars["TF_PLUGIN_CACHE_DIR"])
+	require.NotContains(t, options.EnvVars, "TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE")
+
+	options, _ = GetCommonOptions(&Options{PluginCacheDir: "/cache", PluginCacheMayBreakLock: true}, "init")
+	require.Equal(t, "true", options.EnvVars["TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE"])
+}
+
+func TestInitializedCommands_ShouldFollowFlavorConventions(t *testing.T) {
+	commands := map[string]struct {
+		run          func(options *Options) error
+		expectedArgs string
+	}{
+		"plan": {
+			run: func(options *Options) error {
+				_, err := Plan(options, "tfplan", false)
+				return err
+			},
+			expectedArgs: "plan -out=tfplan -input=false -no-color",
+		},
+		"apply": {
+			run: func(options *Options) error {
+				_, err := Apply(options, "tfplan")
+				return err
+			},
+			expectedArgs: "apply -input=false -no-color -auto-approve=true tfplan",
+		},
+		"import": {
+			run: func(options *Options) error {
+				_, err := Import(options, "aws_s3_bucket.b", "bucket")
+				return err
+			},
+			expectedArgs: "import -input=false aws_s3_bucket.b bucket",
+		},
+		"force-unlock": {
+			run: func(options *Options) error {
+				_, err := ForceUnlock(options, "lock-id")
+				return err
+			},
+			expectedArgs: "force-unlock -force lock-id",
+		},
+	}
+
+	flavorArgs := map[Flavor]string{
+		TerraformFlavor:  "",
+		OpenTofuFlavor:   "",
+		TerragruntFlavor: " --terragrunt-no-auto-init --terragrunt-non-interactive",
+	}
+
+	for name, command := range commands {
+		for flavor, args := range flavorArgs {
+			t.Run(fmt.Sprintf("%s/%s", name, flavor), func(t *testing.T) {
+				binary, argsLog := fakeBinary(t, flavor.Binary(), "")
+
+				options := &Options{
+					TerraformBinary: binary,
+					Flavor:          flavor,
+					Logger:          tfOptions.Logger,
+				}
+
+				require.NoError(t, command.run(options))
+				require.Equal(t, []string{command.expectedArgs + args}, readArgsLog(t, argsLog))
+			})
+		}
+	}
+}
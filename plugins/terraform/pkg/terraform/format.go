@@ -16,6 +16,7 @@ func FormatArgs(options *Options, args ...string) []string {
 	terraformArgs = append(terraformArgs, FormatTerraformVarsAsArgs(options.Vars)...)
 	terraformArgs = append(terraformArgs, FormatTerraformArgs("-var-file", options.VarFiles)...)
 	terraformArgs = append(terraformArgs, FormatTerraformArgs("-target", options.Targets)...)
+	terraformArgs = append(terraformArgs, FormatTerraformArgs("-replace", options.Replaces)...)
 	return terraformArgs
 }
 
@@ -41,6 +42,16 @@ func FormatTerraformBackendConfigAsArgs(vars map[string]interface{}) []string {
 	return formatTerraformArgs(vars, "-backend-config", false)
 }
 
+// FormatTerraformBackendConfigFilesAsArgs formats the given backend configuration files as args for Terraform (e.g. of
+// the format -backend-config=path).
+func FormatTerraformBackendConfigFilesAsArgs(files []string) []string {
+	var args []string
+	for _, file := range files {
+		args = append(args, fmt.Sprintf("-backend-config=%s", file))
+	}
+	return args
+}
+
 // Format the given vars into 'Terraform' format, with each var being prefixed with the given prefix. If
 // useSpaceAsSeparator is true, a space will separate the prefix and each var (e.g., -var foo=bar). If
 // useSpaceAsSeparator is false, an equals will separate the prefix and each var (e.g., -backend-config=foo=bar).
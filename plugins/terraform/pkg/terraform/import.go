@@ -0,0 +1,11 @@
+package terraform
+
+// Import calls terraform import, adopting the existing resource with the ID into the state at the address. Import
+// evaluates the configuration, so the options' vars and var files are passed, but not its targets.
+func Import(options *Options, address string, id string) (string, error) {
+	args := []string{"import", "-input=false"}
+	args = append(args, FormatTerraformVarsAsArgs(options.Vars)...)
+	args = append(args, FormatTerraformArgs("-var-file", options.VarFiles)...)
+
+	return RunTerraformCommand(true, options, InitializedArgs(options, append(args, address, id)...)...)
+}
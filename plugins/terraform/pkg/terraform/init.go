@@ -1,29 +1,32 @@
 package terraform
 
 import (
+	"sort"
 	"strings"
-	"time"
-
-	"github.com/optum/runiac/pkg/retry"
 )
 
-// Init calls terraform init and return stdout/stderr.
+// Init calls terraform init and return stdout/stderr. Retries are the responsibility of the caller.
 func Init(options *Options) (out string, err error) {
 	args := []string{"init", "-force-copy"}
 	backendArgs := FormatTerraformBackendConfigAsArgs(options.BackendConfig)
+	backendArgs = append(backendArgs, FormatTerraformBackendConfigFilesAsArgs(options.BackendConfigFiles)...)
 	args = append(args, backendArgs...)
 
-	options.Logger.Infof("BackendConfig: %v", strings.Join(backendArgs, " "))
-	retryErr := retry.DoWithRetry("terraform init", 3, 10*time.Second, options.Logger, func(attempt int) error {
-		out, err = RunTerraformCommand(true, options, args...)
+	// the values may hold credentials, e.g. a pg conn_str, so only the attributes are logged
+	keys := make([]string, 0, len(options.BackendConfig))
+	for k := range options.BackendConfig {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-		return err
-	})
+	options.Logger.Infof("BackendConfig: %s", strings.Join(append(keys, options.BackendConfigFiles...), " "))
 
-	if retryErr != nil {
-		options.Logger.WithError(retryErr).Error("Error attempting retryable action")
-	}
+	return RunTerraformCommand(true, options, args...)
+}
 
-	return
+// InitProviders calls terraform init without a backend, only installing the required providers, and return stdout/stderr.
+func InitProviders(options *Options) (out string, err error) {
+	args := []string{"init", "-backend=false", "-input=false"}
 
+	return RunTerraformCommand(true, options, args...)
 }
@@ -0,0 +1,65 @@
+package terraform
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LockInfo describes the holder of a state lock, as reported by terraform when it fails to acquire the lock
+type LockInfo struct {
+	ID        string
+	Path      string
+	Operation string
+	Who       string
+	Version   string
+	Created   time.Time
+	Info      string
+}
+
+const lockCreatedLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+var lockErrorRegex = regexp.MustCompile(`(?i)error acquiring the state lock`)
+var lockInfoFieldRegex = regexp.MustCompile(`(?m)^\s*(ID|Path|Operation|Who|Version|Created|Info):[ \t]*(.*?)\s*$`)
+
+// ParseLockInfo detects a state lock error within command output and returns the lock holder information
+func ParseLockInfo(out string) (LockInfo, bool) {
+	if !lockErrorRegex.MatchString(out) {
+		return LockInfo{}, false
+	}
+
+	lock := LockInfo{}
+
+	i := strings.Index(out, "Lock Info:")
+	if i < 0 {
+		return lock, true
+	}
+
+	for _, match := range lockInfoFieldRegex.FindAllStringSubmatch(out[i:], -1) {
+		value := match[2]
+
+		switch match[1] {
+		case "ID":
+			lock.ID = value
+		case "Path":
+			lock.Path = value
+		case "Operation":
+			lock.Operation = value
+		case "Who":
+			lock.Who = value
+		case "Version":
+			lock.Version = value
+		case "Created":
+			lock.Created, _ = time.Parse(lockCreatedLayout, value)
+		case "Info":
+			lock.Info = value
+		}
+	}
+
+	return lock, true
+}
+
+// ForceUnlock calls terraform force-unlock for the lock ID and return stdout/stderr.
+func ForceUnlock(options *Options, lockID string) (string, error) {
+	return RunTerraformCommand(true, options, InitializedArgs(options, "force-unlock", "-force", lockID)...)
+}
@@ -4,25 +4,55 @@ package terraform
 
 import (
 	"encoding/json"
-	"fmt"
-	"reflect"
 	"strings"
 )
 
+// OutputMeta represents a single output as reported by `terraform output -json`, including whether
+// terraform considers the value sensitive
+type OutputMeta struct {
+	Sensitive bool        `json:"sensitive"`
+	Type      interface{} `json:"type,omitempty"`
+	Value     interface{} `json:"value"`
+}
+
 // OutputAll calls terraform and returns all the outputs as a map
 func OutputAll(options *Options) (map[string]interface{}, error) {
 	return OutputForKeysE(options, nil)
 }
 
+// OutputAllWithMeta calls terraform and returns all the outputs, including their sensitivity, as a map
+func OutputAllWithMeta(options *Options) (map[string]OutputMeta, error) {
+	return OutputMetaForKeysE(options, nil)
+}
+
 // OutputForKeysE calls terraform output for the given key list and returns values as a map.
 // The returned values are of type interface{} and need to be type casted as necessary. Refer to output_test.go
 func OutputForKeysE(options *Options, keys []string) (map[string]interface{}, error) {
-	out, err := RunTerraformCommand(false, options, "output", "-no-color", "-json")
+	outputs, err := OutputMetaForKeysE(options, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	resultMap := make(map[string]interface{})
+	for key, output := range outputs {
+		resultMap[key] = output.Value
+	}
+	return resultMap, nil
+}
+
+// OutputMetaForKeysE calls terraform output for the given key list and returns the outputs, including their
+// sensitivity, as a map
+func OutputMetaForKeysE(options *Options, keys []string) (map[string]OutputMeta, error) {
+	out, err := RunTerraformCommandAndGetStdout(options, InitializedArgs(options, "output", "-no-color", "-json")...)
 	if err != nil {
 		return nil, err
 	}
 
-	outputMap := map[string]map[string]interface{}{}
+	return parseOutputJSON(out, keys)
+}
+
+func parseOutputJSON(out string, keys []string) (map[string]OutputMeta, error) {
+	outputMap := map[string]json.RawMessage{}
 	if err := json.Unmarshal([]byte(out), &outputMap); err != nil {
 		return nil, err
 	}
@@ -35,34 +65,40 @@ func OutputForKeysE(options *Options, keys []string) (map[string]interface{}, er
 		keys = outputKeys
 	}
 
-	resultMap := make(map[string]interface{})
+	resultMap := make(map[string]OutputMeta)
 	for _, key := range keys {
-		value, containsValue := outputMap[key]["value"]
-		if !containsValue {
-			return nil, OutputKeyNotFound(string(key))
+		raw, containsKey := outputMap[key]
+		if !containsKey {
+			return nil, OutputKeyNotFound(key)
+		}
+
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
 		}
-		resultMap[key] = value
+
+		if _, containsValue := fields["value"]; !containsValue {
+			return nil, OutputKeyNotFound(key)
+		}
+
+		output := OutputMeta{}
+		if err := json.Unmarshal(raw, &output); err != nil {
+			return nil, err
+		}
+		resultMap[key] = output
 	}
 	return resultMap, nil
 }
 
-// OutputToString converts the value into a string representation since
-// there is no easy win for string representations for e.g. lists or maps in go.
-// This will work for now but starts to break down
-// with complex types, e.g. list of maps.
-func OutputToString(value interface{}) string {
-	var converted string
-	defaultFormat := fmt.Sprintf("%v", value)
-	valueType := reflect.TypeOf(value).String() // Get the type as a string, e.g. []interface {} for an array
-
-	if strings.HasPrefix(valueType, "map") || strings.HasPrefix(valueType, "[]") {
-		j, _ := json.Marshal(value)
-		converted = string(j)
-	} else {
-		converted = defaultFormat
+// SensitiveOutputKeys returns the set of output names terraform flagged as sensitive
+func SensitiveOutputKeys(outputs map[string]OutputMeta) map[string]bool {
+	sensitive := map[string]bool{}
+	for k, v := range outputs {
+		if v.Sensitive {
+			sensitive[k] = true
+		}
 	}
-
-	return converted
+	return sensitive
 }
 
 // Gets the first and last index of the first and last substrings
@@ -10,5 +10,9 @@ func Plan(options *Options, tfplan string, destroy bool) (string, error) {
 		args = append(args, "-destroy")
 	}
 
-	return RunTerraformCommand(true, options, FormatArgs(options, args...)...)
+	if options.JSONUI {
+		args = append(args, "-json")
+	}
+
+	return RunTerraformCommand(true, options, InitializedArgs(options, FormatArgs(options, args...)...)...)
 }
@@ -0,0 +1,28 @@
+package terraform
+
+// DefaultRetryableTerraformErrors are transient errors of terraform commands that are worth retrying. The keys are a
+// regexp to match against the command output and the values are the reason displayed when it is matched.
+var DefaultRetryableTerraformErrors = map[string]string{
+	// provider installation
+	"(?i)failed to (query|install|retrieve) (available )?provider": "Failed to install provider due to a transient registry or network error.",
+	"(?i)could not query provider registry":                        "Failed to query the provider registry due to a transient network error.",
+	"(?i)registry service is unreachable":                          "Provider registry is unreachable.",
+	"(?i)unable to verify (signature|checksum)":                    "Failed to verify provider download due to a transient network error.",
+	"(?i)error installing provider":                                "Failed to install provider due to a transient network error.",
+	"(?i)failed to download module":                                "Failed to download module due to a transient network error.",
+	"(?i)timeout while waiting for plugin to start":                "Provider plugin failed to start in time.",
+	"(?i)timed out waiting for server handshake":                   "Provider plugin failed to start in time.",
+	"(?i)plugin did not respond":                                   "Provider plugin crashed or did not respond.",
+	// network
+	"(?i)connection reset by peer":  "Network connection was reset.",
+	"(?i)tls handshake timeout":     "Network TLS handshake timed out.",
+	"(?i)i/o timeout":               "Network request timed out.",
+	"(?i)no such host":              "DNS lookup failed.",
+	"(?i)unexpected EOF":            "Network connection closed unexpectedly.",
+	"(?i)context deadline exceeded": "Request timed out.",
+	// cloud provider throttling and eventual consistency
+	"(?i)(throttling|requestlimitexceeded|toomanyrequests|rate exceeded)": "Cloud provider API rate limit exceeded.",
+	"(?i)(status ?code|status)[ =:]*(429|500|502|503|504)\\b":             "Cloud provider API returned a transient HTTP error.",
+	"(?i)anotheroperationinprogress":                                      "Another operation is in progress on the resource.",
+	"(?i)retryableerror":                                                  "Cloud provider reported a retryable error.",
+}
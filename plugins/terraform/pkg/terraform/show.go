@@ -2,7 +2,7 @@ package terraform
 
 // Show runs terraform show and returns the output and any error
 func Show(options *Options, tfplan string) (string, error) {
-	args := []string{"show", "-json", tfplan}
+	args := InitializedArgs(options, "show", "-json", tfplan)
 
-	return RunTerraformCommand(false, options, FormatArgs(options, args...)...)
+	return RunTerraformCommandAndGetStdout(options, FormatArgs(options, args...)...)
 }
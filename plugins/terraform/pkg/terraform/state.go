@@ -0,0 +1,63 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StatePull calls terraform state pull and returns the state, which is empty when the workspace has no state yet
+func StatePull(options *Options) (string, error) {
+	return RunTerraformCommandAndGetStdout(options, InitializedArgs(options, "state", "pull")...)
+}
+
+// StatePush calls terraform state push, replacing the workspace's state with the state file
+func StatePush(options *Options, stateFile string) (string, error) {
+	return RunTerraformCommand(true, options, InitializedArgs(options, "state", "push", stateFile)...)
+}
+
+// StateList calls terraform state list and returns the resource addresses in the state, which are empty when the
+// workspace has no state yet
+func StateList(options *Options) ([]string, error) {
+	out, err := RunTerraformCommandAndGetStdout(options, InitializedArgs(options, "state", "list")...)
+	if err != nil {
+		if strings.Contains(err.Error(), "No state file was found") {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	addresses := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if address := strings.TrimSpace(line); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+
+	return addresses, nil
+}
+
+// StateShow calls terraform state show and returns the attributes of the resource
+func StateShow(options *Options, address string) (string, error) {
+	return RunTerraformCommandAndGetStdout(options, InitializedArgs(options, "state", "show", address)...)
+}
+
+// StateMv calls terraform state mv. When stateFile and stateOutFile are set, the resource is moved between the local
+// state files rather than within the workspace's state.
+func StateMv(options *Options, source string, destination string, stateFile string, stateOutFile string) (string, error) {
+	args := []string{"state", "mv"}
+
+	if stateFile != "" {
+		args = append(args, fmt.Sprintf("-state=%s", stateFile))
+	}
+
+	if stateOutFile != "" {
+		args = append(args, fmt.Sprintf("-state-out=%s", stateOutFile))
+	}
+
+	return RunTerraformCommand(true, options, InitializedArgs(options, append(args, source, destination)...)...)
+}
+
+// StateRm calls terraform state rm, removing the resources from the workspace's state without destroying them
+func StateRm(options *Options, addresses ...string) (string, error) {
+	return RunTerraformCommand(true, options, InitializedArgs(options, append([]string{"state", "rm"}, addresses...)...)...)
+}
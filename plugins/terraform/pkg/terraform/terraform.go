@@ -3,6 +3,10 @@ package terraform
 
 // This code follows: https://github.com/gruntwork-io/terratest/blob/master/modules/terraform/terraform.go
 
+import (
+	"github.com/optum/runiac/pkg/config"
+)
+
 // https://www.terraform.io/docs/commands/plan.html#detailed-exitcode
 
 // TerraformPlanChangesPresentExitCode is the exit code returned by terraform plan detailed exitcode when changes are present
@@ -20,10 +24,22 @@ type Terraformer interface {
 	Plan(options *Options, tfplan string, destroy bool) (string, error)
 	OutputAll(options *Options) (map[string]interface{}, error)
 	OutputForKeysE(options *Options, keys []string) (map[string]interface{}, error)
+	OutputAllWithMeta(options *Options) (map[string]OutputMeta, error)
 	OutputToString(value interface{}) string
 	Init(options *Options) (out string, err error)
+	InitProviders(options *Options) (out string, err error)
 	Apply(options *Options, tfplan string) (string, error)
 	WorkspaceSelect(options *Options, workspace string) (string, error)
+	ForceUnlock(options *Options, lockID string) (string, error)
+	StatePull(options *Options) (string, error)
+	StatePush(options *Options, stateFile string) (string, error)
+	StateList(options *Options) ([]string, error)
+	StateShow(options *Options, address string) (string, error)
+	StateMv(options *Options, source string, destination string, stateFile string, stateOutFile string) (string, error)
+	StateRm(options *Options, addresses ...string) (string, error)
+	Import(options *Options, address string, id string) (string, error)
+	FmtCheck(options *Options) (string, error)
+	Validate(options *Options) (string, error)
 }
 
 type Terraform struct{}
@@ -48,18 +64,66 @@ func (t Terraform) OutputForKeysE(options *Options, keys []string) (map[string]i
 	return OutputForKeysE(options, keys)
 }
 
+func (t Terraform) OutputAllWithMeta(options *Options) (map[string]OutputMeta, error) {
+	return OutputAllWithMeta(options)
+}
+
 func (t Terraform) Init(options *Options) (out string, err error) {
 	return Init(options)
 }
 
+func (t Terraform) InitProviders(options *Options) (out string, err error) {
+	return InitProviders(options)
+}
+
 func (t Terraform) Apply(options *Options, tfplan string) (string, error) {
 	return Apply(options, tfplan)
 }
 
 func (t Terraform) OutputToString(value interface{}) string {
-	return OutputToString(value)
+	return config.OutputToString(value)
 }
 
 func (t Terraform) WorkspaceSelect(options *Options, workspace string) (string, error) {
 	return WorkspaceSelect(options, workspace)
 }
+
+func (t Terraform) ForceUnlock(options *Options, lockID string) (string, error) {
+	return ForceUnlock(options, lockID)
+}
+
+func (t Terraform) StatePull(options *Options) (string, error) {
+	return StatePull(options)
+}
+
+func (t Terraform) StatePush(options *Options, stateFile string) (string, error) {
+	return StatePush(options, stateFile)
+}
+
+func (t Terraform) StateList(options *Options) ([]string, error) {
+	return StateList(options)
+}
+
+func (t Terraform) StateShow(options *Options, address string) (string, error) {
+	return StateShow(options, address)
+}
+
+func (t Terraform) StateMv(options *Options, source string, destination string, stateFile string, stateOutFile string) (string, error) {
+	return StateMv(options, source, destination, stateFile, stateOutFile)
+}
+
+func (t Terraform) StateRm(options *Options, addresses ...string) (string, error) {
+	return StateRm(options, addresses...)
+}
+
+func (t Terraform) Import(options *Options, address string, id string) (string, error) {
+	return Import(options, address, id)
+}
+
+func (t Terraform) FmtCheck(options *Options) (string, error) {
+	return FmtCheck(options)
+}
+
+func (t Terraform) Validate(options *Options) (string, error) {
+	return Validate(options)
+}
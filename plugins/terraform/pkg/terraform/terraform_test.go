@@ -1,8 +1,10 @@
 package terraform
 
 import (
+	"github.com/optum/runiac/pkg/retry"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
 )
@@ -63,35 +65,139 @@ func TestGetFirstAndLastIndex(t *testing.T) {
 	}
 }
 
-func TestOutputToString(t *testing.T) {
-	tests := []struct {
-		Value          interface{}
-		ExpectedString string
-	}{
-		{
-			Value:          "vpcid-123",
-			ExpectedString: "vpcid-123",
-		},
-		{
-			Value:          []interface{}{"subnet1", "subnet2", "subnet3"},
-			ExpectedString: "[\"subnet1\",\"subnet2\",\"subnet3\"]",
-		},
-		{
-			Value:          []interface{}{},
-			ExpectedString: "[]",
-		},
-		{
-			Value:          map[string]interface{}{"k1": "v1", "k2": "v2"},
-			ExpectedString: "{\"k1\":\"v1\",\"k2\":\"v2\"}",
-		},
-		{
-			Value:          map[string]map[string][]string{"AZU": {"UK": []string{"uk1", "uk2"}, "US": []string{"us1", "us2"}}, "AWS": {"UK": []string{"uk1"}}, "GCP": {"UK": []string{"uk1"}}},
-			ExpectedString: "{\"AWS\":{\"UK\":[\"uk1\"]},\"AZU\":{\"UK\":[\"uk1\",\"uk2\"],\"US\":[\"us1\",\"us2\"]},\"GCP\":{\"UK\":[\"uk1\"]}}",
-		},
+func TestParseOutputJSON_ShouldTrackSensitivity(t *testing.T) {
+	out := `{
+  "db_password": {"sensitive": true, "type": "string", "value": "hunter2"},
+  "names": {"sensitive": false, "type": ["list", "string"], "value": ["a", "b"]}
+}`
+
+	outputs, err := parseOutputJSON(out, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "hunter2", outputs["db_password"].Value)
+	assert.True(t, outputs["db_password"].Sensitive)
+	assert.False(t, outputs["names"].Sensitive)
+	assert.Equal(t, map[string]bool{"db_password": true}, SensitiveOutputKeys(outputs))
+}
+
+func TestParseOutputJSON_ShouldErrorOnMissingKey(t *testing.T) {
+	_, err := parseOutputJSON(`{"a": {"sensitive": false, "value": "b"}}`, []string{"missing"})
+
+	assert.Equal(t, OutputKeyNotFound("missing"), err)
+}
+
+func TestFormatArgs_ShouldIncludeTargetsAndReplaces(t *testing.T) {
+	args := FormatArgs(&Options{
+		VarFiles: []string{"vars/common.tfvars"},
+		Targets:  []string{"aws_vpc.main"},
+		Replaces: []string{"aws_instance.main"},
+	}, "plan")
+
+	assert.Equal(t, []string{"plan", "-var-file", "vars/common.tfvars", "-target", "aws_vpc.main", "-replace", "aws_instance.main"}, args)
+}
+
+func TestDefaultRetryableTerraformErrors_ShouldOnlyMatchTransientErrors(t *testing.T) {
+	retryableErrors, err := retry.CompileRetryableErrors(DefaultRetryableTerraformErrors)
+	require.NoError(t, err)
+
+	transient := []string{
+		"Error: Failed to query available provider packages",
+		"Error: Failed to install provider",
+		"dial tcp 10.0.0.1:443: i/o timeout",
+		"read: connection reset by peer",
+		"Error: error creating S3 bucket: Throttling: Rate exceeded",
+		"StatusCode=429 -- Original Error: autorest/azure: Service returned an error",
+		"Error: googleapi: Error 503: Service Unavailable, status: 503",
 	}
 
-	for _, tc := range tests {
-		result := OutputToString(tc.Value)
-		assert.Equal(t, tc.ExpectedString, result)
+	for _, message := range transient {
+		_, ok := retry.MatchRetryableError(retryableErrors, message)
+		assert.True(t, ok, message)
 	}
+
+	permanent := []string{
+		"Error: Invalid reference",
+		"Error: Unsupported argument",
+		"Error: Error acquiring the state lock",
+		"Sentinel policy check failed",
+	}
+
+	for _, message := range permanent {
+		_, ok := retry.MatchRetryableError(retryableErrors, message)
+		assert.False(t, ok, message)
+	}
+}
+
+func TestParseLockInfo_ShouldExtractLockHolder(t *testing.T) {
+	out := `
+Error: Error acquiring the state lock
+
+Error message: ConditionalCheckFailedException: The conditional request failed
+Lock Info:
+  ID:        5f2c1a3e-7b8d-4c1e-9f00-1234567890ab
+  Path:      bucket/default/network/primary-us-east-1.tfstate
+  Operation: OperationTypeApply
+  Who:       root@3f9c2d1e0a4b
+  Version:   1.3.9
+  Created:   2023-03-01 12:30:45.123456789 +0000 UTC
+  Info:      
+
+Terraform acquires a state lock to protect the state from being written
+by multiple users at the same time.
+`
+
+	lock, ok := ParseLockInfo(out)
+
+	assert.True(t, ok)
+	assert.Equal(t, "5f2c1a3e-7b8d-4c1e-9f00-1234567890ab", lock.ID)
+	assert.Equal(t, "bucket/default/network/primary-us-east-1.tfstate", lock.Path)
+	assert.Equal(t, "OperationTypeApply", lock.Operation)
+	assert.Equal(t, "root@3f9c2d1e0a4b", lock.Who)
+	assert.Equal(t, "1.3.9", lock.Version)
+	assert.Equal(t, 2023, lock.Created.Year())
+	assert.Equal(t, "", lock.Info)
+
+	_, ok = ParseLockInfo("Error: Invalid reference")
+	assert.False(t, ok)
+}
+
+const stubJSONUIOutput = `{"@level":"info","@message":"Terraform 1.3.9","@module":"terraform.ui","type":"version","terraform":"1.3.9","ui":"1.0"}
+{"@level":"info","@message":"aws_vpc.main: Creating...","@module":"terraform.ui","type":"apply_start","hook":{"resource":{"addr":"aws_vpc.main","module":"","resource":"aws_vpc.main","resource_type":"aws_vpc","resource_name":"main"},"action":"create"}}
+{"@level":"info","@message":"aws_vpc.main: Creation complete after 2s [id=vpc-123]","@module":"terraform.ui","type":"apply_complete","hook":{"resource":{"addr":"aws_vpc.main","resource_type":"aws_vpc","resource_name":"main"},"action":"create","id_key":"id","id_value":"vpc-123","elapsed_seconds":2}}
+not a machine readable line
+{"@level":"error","@message":"Error: Unsupported argument","@module":"terraform.ui","type":"diagnostic","diagnostic":{"severity":"error","summary":"Unsupported argument","detail":"An argument named \"cidr\" is not expected here.","range":{"filename":"main.tf","start":{"line":12,"column":3,"byte":200},"end":{"line":12,"column":7,"byte":204}}}}`
+
+func TestParseUIEvents_ShouldParseTypedEvents(t *testing.T) {
+	events := ParseUIEvents(stubJSONUIOutput)
+
+	assert.Len(t, events, 4)
+	assert.Equal(t, "version", events[0].Type)
+	assert.Equal(t, UIEventApplyStart, events[1].Type)
+	assert.Equal(t, "aws_vpc.main", events[1].Hook.Resource.Addr)
+	assert.Equal(t, UIEventApplyComplete, events[2].Type)
+	assert.Equal(t, "vpc-123", events[2].Hook.IDValue)
+	assert.Equal(t, float64(2), events[2].Hook.ElapsedSeconds)
+
+	diagnostics := ErrorDiagnostics(events)
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, `Error: Unsupported argument (main.tf:12): An argument named "cidr" is not expected here.`, diagnostics[0].String())
+}
+
+func TestParseUIEvent_ShouldSkipOtherLines(t *testing.T) {
+	for _, line := range []string{"", "Plan: 1 to add", "{}", `{"a": 1}`, "{not json"} {
+		_, ok := ParseUIEvent(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestUIOutputText_ShouldRenderDiagnosticsForLockParsing(t *testing.T) {
+	out := `{"@level":"error","@message":"Error: Error acquiring the state lock","type":"diagnostic","diagnostic":{"severity":"error","summary":"Error acquiring the state lock","detail":"Error message: resource temporarily unavailable\nLock Info:\n  ID:        stub-lock-id\n  Path:      stub/path\n  Operation: OperationTypeApply\n  Who:       root@killed-container\n  Version:   1.3.9\n  Created:   2023-03-01 12:30:45.123456789 +0000 UTC\n  Info:\n"}}`
+
+	lock, ok := ParseLockInfo(UIOutputText(out))
+
+	assert.True(t, ok)
+	assert.Equal(t, "stub-lock-id", lock.ID)
+	assert.Equal(t, "root@killed-container", lock.Who)
+
+	assert.Equal(t, "Plan: 1 to add\n", UIOutputText("Plan: 1 to add\n"))
 }
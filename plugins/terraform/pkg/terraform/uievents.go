@@ -0,0 +1,251 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Types of the terraform machine readable UI events runiac handles, other types are logged by their message
+const (
+	UIEventDiagnostic    = "diagnostic"
+	UIEventPlannedChange = "planned_change"
+	UIEventChangeSummary = "change_summary"
+	UIEventApplyStart    = "apply_start"
+	UIEventApplyProgress = "apply_progress"
+	UIEventApplyComplete = "apply_complete"
+	UIEventApplyErrored  = "apply_errored"
+)
+
+// UIEvent is a line of terraform's machine readable UI, streamed by plan and apply with -json
+type UIEvent struct {
+	Level      string           `json:"@level"`
+	Message    string           `json:"@message"`
+	Timestamp  string           `json:"@timestamp"`
+	Type       string           `json:"type"`
+	Hook       *UIHook          `json:"hook,omitempty"`       // Set for apply_* events
+	Change     *UIChange        `json:"change,omitempty"`     // Set for planned_change events
+	Changes    *UIChangeSummary `json:"changes,omitempty"`    // Set for change_summary events
+	Diagnostic *UIDiagnostic    `json:"diagnostic,omitempty"` // Set for diagnostic events
+}
+
+// UIResource identifies the resource of an event
+type UIResource struct {
+	Addr         string `json:"addr"`
+	Module       string `json:"module"`
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+}
+
+// UIHook is the progress of applying a change to a resource
+type UIHook struct {
+	Resource       UIResource `json:"resource"`
+	Action         string     `json:"action"`
+	IDKey          string     `json:"id_key"`
+	IDValue        string     `json:"id_value"`
+	ElapsedSeconds float64    `json:"elapsed_seconds"`
+}
+
+// UIChange is a change to a resource planned by terraform
+type UIChange struct {
+	Resource UIResource `json:"resource"`
+	Action   string     `json:"action"`
+}
+
+// UIChangeSummary counts the changes of a plan or apply
+type UIChangeSummary struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Remove    int    `json:"remove"`
+	Import    int    `json:"import"`
+	Operation string `json:"operation"`
+}
+
+// UIDiagnostic is an error or warning reported by terraform, located in the configuration when known
+type UIDiagnostic struct {
+	Severity string   `json:"severity"`
+	Summary  string   `json:"summary"`
+	Detail   string   `json:"detail"`
+	Address  string   `json:"address"`
+	Range    *UIRange `json:"range,omitempty"`
+}
+
+// UIRange is a range within a configuration file
+type UIRange struct {
+	Filename string `json:"filename"`
+	Start    UIPos  `json:"start"`
+	End      UIPos  `json:"end"`
+}
+
+// UIPos is a position within a configuration file
+type UIPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// String formats the diagnostic as terraform does, e.g. Error: Unsupported argument (main.tf:12): An argument named...
+func (d UIDiagnostic) String() string {
+	severity := d.Severity
+	if severity != "" {
+		severity = strings.ToUpper(severity[:1]) + severity[1:]
+	}
+
+	s := fmt.Sprintf("%s: %s", severity, d.Summary)
+
+	if d.Range != nil && d.Range.Filename != "" {
+		s += fmt.Sprintf(" (%s:%d)", d.Range.Filename, d.Range.Start.Line)
+	}
+
+	if detail := strings.TrimSpace(d.Detail); detail != "" {
+		s += ": " + detail
+	}
+
+	return s
+}
+
+// ParseUIEvent parses a line of terraform's machine readable UI, returning false for any other line
+func ParseUIEvent(line string) (UIEvent, bool) {
+	line = strings.TrimSpace(line)
+
+	if !strings.HasPrefix(line, "{") {
+		return UIEvent{}, false
+	}
+
+	event := UIEvent{}
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Type == "" {
+		return UIEvent{}, false
+	}
+
+	return event, true
+}
+
+// ParseUIEvents parses the machine readable UI events within a command's output, skipping any other lines
+func ParseUIEvents(out string) []UIEvent {
+	events := []UIEvent{}
+
+	for _, line := range strings.Split(out, "\n") {
+		if event, ok := ParseUIEvent(line); ok {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// UIOutputText renders the machine readable UI events within a command's output as terraform's human readable
+// messages, including the detail of diagnostics. Other lines are kept, so text output is returned unchanged.
+func UIOutputText(out string) string {
+	lines := []string{}
+
+	for _, line := range strings.Split(out, "\n") {
+		event, ok := ParseUIEvent(line)
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+
+		lines = append(lines, event.Message)
+
+		if event.Diagnostic != nil && event.Diagnostic.Detail != "" {
+			lines = append(lines, event.Diagnostic.Detail)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ErrorDiagnostics returns the error diagnostics of the events
+func ErrorDiagnostics(events []UIEvent) []UIDiagnostic {
+	diagnostics := []UIDiagnostic{}
+
+	for _, event := range events {
+		if event.Diagnostic != nil && event.Diagnostic.Severity == "error" {
+			diagnostics = append(diagnostics, *event.Diagnostic)
+		}
+	}
+
+	return diagnostics
+}
+
+// DiagnosticsError is the error of a failed command with the error diagnostics terraform reported
+type DiagnosticsError struct {
+	Err         error
+	Diagnostics []UIDiagnostic
+}
+
+func (e *DiagnosticsError) Error() string {
+	lines := []string{e.Err.Error()}
+
+	for _, d := range e.Diagnostics {
+		lines = append(lines, d.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (e *DiagnosticsError) Unwrap() error {
+	return e.Err
+}
+
+// LogUIEvent logs the event at its level with the resource, action and location as fields
+func LogUIEvent(logger *logrus.Entry, event UIEvent) {
+	logger = logger.WithField("event", event.Type)
+
+	if event.Hook != nil {
+		logger = logger.WithFields(logrus.Fields{
+			"resource":       event.Hook.Resource.Addr,
+			"resourceAction": event.Hook.Action,
+		})
+
+		if event.Hook.ElapsedSeconds > 0 {
+			logger = logger.WithField("elapsed", event.Hook.ElapsedSeconds)
+		}
+	}
+
+	if event.Change != nil {
+		logger = logger.WithFields(logrus.Fields{
+			"resource":       event.Change.Resource.Addr,
+			"resourceAction": event.Change.Action,
+		})
+	}
+
+	if event.Changes != nil {
+		logger = logger.WithFields(logrus.Fields{
+			"add":    event.Changes.Add,
+			"change": event.Changes.Change,
+			"remove": event.Changes.Remove,
+		})
+	}
+
+	message := event.Message
+
+	if event.Diagnostic != nil {
+		if event.Diagnostic.Address != "" {
+			logger = logger.WithField("resource", event.Diagnostic.Address)
+		}
+
+		if event.Diagnostic.Range != nil {
+			logger = logger.WithFields(logrus.Fields{
+				"file": event.Diagnostic.Range.Filename,
+				"line": event.Diagnostic.Range.Start.Line,
+			})
+		}
+
+		if detail := strings.TrimSpace(event.Diagnostic.Detail); detail != "" {
+			message += "\n" + detail
+		}
+	}
+
+	switch event.Level {
+	case "error":
+		logger.Error(message)
+	case "warn":
+		logger.Warn(message)
+	case "debug", "trace":
+		logger.Debug(message)
+	default:
+		logger.Info(message)
+	}
+}
@@ -0,0 +1,13 @@
+package terraform
+
+// FmtCheck calls terraform fmt -check and returns the files that are not formatted canonically, the check fails when
+// any are listed
+func FmtCheck(options *Options) (string, error) {
+	return RunTerraformCommandAndGetStdout(options, "fmt", "-check", "-list=true")
+}
+
+// Validate calls terraform validate and returns stdout/stderr. The working directory must be initialized, which does
+// not require a backend, e.g. with InitProviders.
+func Validate(options *Options) (string, error) {
+	return RunTerraformCommand(false, options, "validate")
+}
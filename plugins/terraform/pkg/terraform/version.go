@@ -2,7 +2,18 @@ package terraform
 
 // displays terraform version string
 func Version(options *Options) (string, error) {
-	args := []string{"version"}
+	options, _ = GetCommonOptions(options)
 
-	return RunTerraformCommand(false, options, FormatArgs(options, args...)...)
+	// the version command does not accept the flavor's common args
+	return RunTerraformCommandWithoutCommonArgs(false, options, flavors[options.Flavor].VersionArgs...)
+}
+
+// DetectVersion runs the version command and returns the detected flavor and version
+func DetectVersion(options *Options) (Flavor, string, error) {
+	out, err := Version(options)
+	if err != nil {
+		return "", "", err
+	}
+
+	return ParseVersionOutput(out)
 }
@@ -5,14 +5,18 @@ import (
 	"strings"
 )
 
-// Plan runs terraform plan with the given options and returns stdout/stderr.
+// WorkspaceSelect selects the workspace, creating it when it does not exist, and returns stdout/stderr.
 func WorkspaceSelect(options *Options, ws string) (string, error) {
-	args := []string{"workspace", "select", ws}
+	if flavors[options.Flavor].WorkspaceOrCreate {
+		return RunTerraformCommand(true, options, FormatArgs(options, InitializedArgs(options, "workspace", "select", "-or-create", ws)...)...)
+	}
+
+	args := InitializedArgs(options, "workspace", "select", ws)
 
 	resp, err := RunTerraformCommand(true, options, FormatArgs(options, args...)...)
 
 	if err != nil && strings.Contains(strings.ToLower(resp), strings.ToLower(fmt.Sprintf("workspace \"%s\" doesn't exist", ws))) {
-		argsNew := []string{"workspace", "new", ws}
+		argsNew := InitializedArgs(options, "workspace", "new", ws)
 		resp2, err2 := RunTerraformCommand(true, options, FormatArgs(options, argsNew...)...)
 
 		if err2 != nil {
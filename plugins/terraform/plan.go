@@ -0,0 +1,306 @@
+package plugins_terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+)
+
+var identifierPathKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// summarizePlan normalizes the plan into a runner agnostic summary with the attribute level changes of each resource,
+// masking sensitive values
+func summarizePlan(p plan) config.PlanSummary {
+	summary := config.PlanSummary{
+		ResourceChanges: []config.PlannedResourceChange{},
+		ResourceDrift:   []config.PlannedResourceChange{},
+		OutputChanges:   []config.PlannedOutputChange{},
+	}
+
+	for _, rc := range p.ResourceChanges {
+		c := summarizeResourceChange(rc)
+
+		switch c.Action {
+		case config.PlanActionCreate:
+			summary.Add++
+		case config.PlanActionUpdate:
+			summary.Change++
+		case config.PlanActionDelete:
+			summary.Destroy++
+		case config.PlanActionReplace:
+			summary.Add++
+			summary.Destroy++
+		}
+
+		summary.ResourceChanges = append(summary.ResourceChanges, c)
+	}
+
+	for _, rc := range p.ResourceDrift {
+		summary.ResourceDrift = append(summary.ResourceDrift, summarizeResourceChange(rc))
+	}
+
+	names := []string{}
+	for name := range p.OutputChanges {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		oc := p.OutputChanges[name]
+
+		c := config.PlannedOutputChange{
+			Name:      name,
+			Action:    planAction(oc.Actions),
+			Before:    decodePlanValue(oc.Before),
+			After:     decodePlanValue(oc.After),
+			Unknown:   decodePlanValue(oc.AfterUnknown) == true,
+			Sensitive: p.PlannedValues.Outputs[name].Sensitive,
+		}
+
+		if decodePlanValue(oc.BeforeSensitive) == true || decodePlanValue(oc.AfterSensitive) == true {
+			c.Sensitive = true
+		}
+
+		if c.Sensitive {
+			c.Before = maskPlanValue(c.Before)
+			c.After = maskPlanValue(c.After)
+		}
+
+		summary.OutputChanges = append(summary.OutputChanges, c)
+	}
+
+	return summary
+}
+
+// summarizeResourceChange normalizes the change of a resource instance
+func summarizeResourceChange(rc resourceChange) config.PlannedResourceChange {
+	c := config.PlannedResourceChange{
+		Address:          rc.Address,
+		PreviousAddress:  rc.PreviousAddress,
+		ModuleAddress:    rc.ModuleAddress,
+		Mode:             rc.Mode,
+		Type:             rc.Type,
+		Name:             rc.Name,
+		ProviderName:     rc.ProviderName,
+		Action:           planAction(rc.Change.Actions),
+		ActionReason:     rc.ActionReason,
+		ReplacePaths:     []string{},
+		AttributeChanges: attributeChanges(rc.Change),
+	}
+
+	for _, path := range rc.Change.ReplacePaths {
+		c.ReplacePaths = append(c.ReplacePaths, formatPlanPath(path))
+	}
+
+	return c
+}
+
+// planAction normalizes the actions of a change, e.g. ["delete", "create"] is a replace
+func planAction(actions []string) string {
+	switch strings.Join(actions, ",") {
+	case "create":
+		return config.PlanActionCreate
+	case "read":
+		return config.PlanActionRead
+	case "update":
+		return config.PlanActionUpdate
+	case "delete":
+		return config.PlanActionDelete
+	case "delete,create", "create,delete":
+		return config.PlanActionReplace
+	default:
+		return config.PlanActionNoOp
+	}
+}
+
+// attributeChanges compares the leaf values of the object before and after the change, returning the changed
+// attributes sorted by path. Attributes that are only known after apply are reported once at the unknown path.
+func attributeChanges(c change) []config.AttributeChange {
+	before := flattenPlanValue(decodePlanValue(c.Before))
+	after := flattenPlanValue(decodePlanValue(c.After))
+	unknown := truePlanPaths(decodePlanValue(c.AfterUnknown))
+	beforeSensitive := truePlanPaths(decodePlanValue(c.BeforeSensitive))
+	afterSensitive := truePlanPaths(decodePlanValue(c.AfterSensitive))
+
+	paths := map[string]bool{}
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+
+	changes := []config.AttributeChange{}
+
+	for path := range paths {
+		if planPathCovered(unknown, path) {
+			continue
+		}
+
+		b, a := before[path], after[path]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+
+		changes = append(changes, maskAttributeChange(config.AttributeChange{Path: path, Before: b, After: a}, beforeSensitive, afterSensitive))
+	}
+
+	for path := range unknown {
+		changes = append(changes, maskAttributeChange(config.AttributeChange{Path: path, Before: before[path], Unknown: true}, beforeSensitive, afterSensitive))
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+// maskAttributeChange masks the values of the change when its attribute, or an attribute containing it, is sensitive
+func maskAttributeChange(c config.AttributeChange, beforeSensitive map[string]bool, afterSensitive map[string]bool) config.AttributeChange {
+	if planPathCovered(beforeSensitive, c.Path) {
+		c.Before = maskPlanValue(c.Before)
+		c.Sensitive = true
+	}
+
+	if planPathCovered(afterSensitive, c.Path) {
+		c.After = maskPlanValue(c.After)
+		c.Sensitive = true
+	}
+
+	return c
+}
+
+// maskPlanValue masks a value, keeping unset values unset
+func maskPlanValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return config.MaskedValue
+}
+
+// decodePlanValue decodes a json value of the plan, returning nil for unset values
+func decodePlanValue(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil
+	}
+
+	return v
+}
+
+// flattenPlanValue flattens an object value into its leaf values by path, e.g. tags.Name or subnet_ids[0]. Empty
+// objects and lists are leaf values, so adding or removing them is reported.
+func flattenPlanValue(v interface{}) map[string]interface{} {
+	values := map[string]interface{}{}
+
+	var flatten func(path string, v interface{})
+	flatten = func(path string, v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if len(t) == 0 && path != "" {
+				values[path] = t
+			}
+			for key, child := range t {
+				flatten(joinPlanPath(path, key), child)
+			}
+		case []interface{}:
+			if len(t) == 0 && path != "" {
+				values[path] = t
+			}
+			for i, child := range t {
+				flatten(joinPlanPath(path, float64(i)), child)
+			}
+		default:
+			if path != "" && t != nil {
+				values[path] = t
+			}
+		}
+	}
+
+	flatten("", v)
+
+	return values
+}
+
+// truePlanPaths returns the paths of the true leaf values of a structure mirroring an object value, such as
+// after_unknown or after_sensitive. A path of "" means the whole object.
+func truePlanPaths(v interface{}) map[string]bool {
+	paths := map[string]bool{}
+
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for key, child := range t {
+				walk(joinPlanPath(path, key), child)
+			}
+		case []interface{}:
+			for i, child := range t {
+				walk(joinPlanPath(path, float64(i)), child)
+			}
+		case bool:
+			if t {
+				paths[path] = true
+			}
+		}
+	}
+
+	walk("", v)
+
+	return paths
+}
+
+// planPathCovered returns whether the path, or a path containing it, is in the set. The path "" is the whole object.
+func planPathCovered(paths map[string]bool, path string) bool {
+	if paths[""] {
+		return true
+	}
+
+	for p := range paths {
+		if p == path || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// joinPlanPath appends an attribute name or collection key to a path
+func joinPlanPath(path string, step interface{}) string {
+	switch s := step.(type) {
+	case string:
+		if !identifierPathKey.MatchString(s) {
+			return fmt.Sprintf("%s[%q]", path, s)
+		}
+		if path == "" {
+			return s
+		}
+		return path + "." + s
+	case float64:
+		return fmt.Sprintf("%s[%d]", path, int(s))
+	default:
+		return fmt.Sprintf("%s[%v]", path, s)
+	}
+}
+
+// formatPlanPath formats a path of attribute names and collection keys, such as replace_paths, e.g. tags.Name
+func formatPlanPath(steps []interface{}) string {
+	path := ""
+	for _, step := range steps {
+		path = joinPlanPath(path, step)
+	}
+
+	return path
+}
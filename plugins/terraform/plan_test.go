@@ -0,0 +1,125 @@
+package plugins_terraform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+const planJSON = `{
+  "format_version": "0.2",
+  "terraform_version": "1.1.9",
+  "variables": {"name": {"value": "app"}},
+  "planned_values": {
+    "outputs": {"password": {"sensitive": true}, "vpc_id": {"sensitive": false}},
+    "root_module": {"resources": [{"address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main", "schema_version": 1, "values": {"cidr_block": "10.1.0.0/16"}}]}
+  },
+  "resource_drift": [
+    {
+      "address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+      "change": {"actions": ["update"], "before": {"tags": {"Owner": "a"}}, "after": {"tags": {"Owner": "b"}}, "after_unknown": {}}
+    }
+  ],
+  "resource_changes": [
+    {
+      "address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main", "provider_name": "registry.terraform.io/hashicorp/aws",
+      "action_reason": "replace_because_cannot_update",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"cidr_block": "10.0.0.0/16", "id": "vpc-1", "tags": {"Name": "main", "kubernetes.io/role": "x"}},
+        "after": {"cidr_block": "10.1.0.0/16", "tags": {"Name": "main", "kubernetes.io/role": "y"}},
+        "after_unknown": {"id": true, "tags": {}},
+        "before_sensitive": {}, "after_sensitive": {},
+        "replace_paths": [["cidr_block"]]
+      }
+    },
+    {
+      "address": "aws_db_instance.db[0]", "mode": "managed", "type": "aws_db_instance", "name": "db", "index": 0,
+      "change": {
+        "actions": ["update"],
+        "before": {"password": "old", "subnet_ids": ["a"], "size": 10},
+        "after": {"password": "new", "subnet_ids": ["a", "b"], "size": 10},
+        "after_unknown": {},
+        "before_sensitive": {"password": true}, "after_sensitive": {"password": true}
+      }
+    },
+    {
+      "address": "aws_iam_role.r", "mode": "managed", "type": "aws_iam_role", "name": "r", "previous_address": "aws_iam_role.old",
+      "change": {"actions": ["no-op"], "before": {"name": "r"}, "after": {"name": "r"}, "after_unknown": {}}
+    }
+  ],
+  "output_changes": {
+    "vpc_id": {"actions": ["update"], "before": "vpc-1", "after_unknown": true},
+    "password": {"actions": ["create"], "after": "secret", "after_unknown": false}
+  }
+}`
+
+func TestSummarizePlan_ShouldNormalizeChanges(t *testing.T) {
+	p := plan{}
+	require.NoError(t, json.Unmarshal([]byte(planJSON), &p))
+
+	summary := summarizePlan(p)
+
+	require.Equal(t, 1, summary.Add)
+	require.Equal(t, 1, summary.Change)
+	require.Equal(t, 1, summary.Destroy)
+	require.True(t, summary.HasChanges())
+
+	require.Len(t, summary.ResourceChanges, 3)
+
+	vpc := summary.ResourceChanges[0]
+	require.Equal(t, config.PlanActionReplace, vpc.Action)
+	require.Equal(t, "replace_because_cannot_update", vpc.ActionReason)
+	require.Equal(t, []string{"cidr_block"}, vpc.ReplacePaths)
+	require.Equal(t, []config.AttributeChange{
+		{Path: "cidr_block", Before: "10.0.0.0/16", After: "10.1.0.0/16"},
+		{Path: "id", Before: "vpc-1", Unknown: true},
+		{Path: `tags["kubernetes.io/role"]`, Before: "x", After: "y"},
+	}, vpc.AttributeChanges)
+
+	db := summary.ResourceChanges[1]
+	require.Equal(t, config.PlanActionUpdate, db.Action)
+	require.Equal(t, []config.AttributeChange{
+		{Path: "password", Before: config.MaskedValue, After: config.MaskedValue, Sensitive: true},
+		{Path: "subnet_ids[1]", After: "b"},
+	}, db.AttributeChanges)
+
+	role := summary.ResourceChanges[2]
+	require.Equal(t, config.PlanActionNoOp, role.Action)
+	require.Equal(t, "aws_iam_role.old", role.PreviousAddress)
+	require.Empty(t, role.AttributeChanges)
+
+	require.Len(t, summary.ResourceDrift, 1)
+	require.Equal(t, "aws_s3_bucket.logs", summary.ResourceDrift[0].Address)
+	require.Equal(t, []config.AttributeChange{
+		{Path: "tags.Owner", Before: "a", After: "b"},
+	}, summary.ResourceDrift[0].AttributeChanges)
+
+	require.Equal(t, []config.PlannedOutputChange{
+		{Name: "password", Action: config.PlanActionCreate, After: config.MaskedValue, Sensitive: true},
+		{Name: "vpc_id", Action: config.PlanActionUpdate, Before: "vpc-1", Unknown: true},
+	}, summary.OutputChanges)
+}
+
+func TestSummarizePlan_ShouldMaskWhollySensitiveObjects(t *testing.T) {
+	changes := attributeChanges(change{
+		Actions:        []string{"create"},
+		After:          json.RawMessage(`{"data": {"key": "value"}}`),
+		AfterUnknown:   json.RawMessage(`{}`),
+		AfterSensitive: json.RawMessage(`true`),
+	})
+
+	require.Equal(t, []config.AttributeChange{
+		{Path: "data.key", After: config.MaskedValue, Sensitive: true},
+	}, changes)
+}
+
+func TestSummarizePlan_ShouldReportNoChanges(t *testing.T) {
+	summary := summarizePlan(plan{
+		ResourceChanges: []resourceChange{{Address: "aws_vpc.main", Change: change{Actions: []string{"no-op"}}}},
+	})
+
+	require.False(t, summary.HasChanges())
+}
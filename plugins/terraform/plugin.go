@@ -1,19 +1,36 @@
 package plugins_terraform
 
 import (
+	"path/filepath"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/retry"
 	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 )
 
-type TerraformPlugin struct {}
+type TerraformPlugin struct {
+	Flavor               string   // The configured terraform compatible CLI: terraform, tofu or terragrunt
+	PluginCacheDir       string   // Provider plugin cache shared by all steps, warmed once per run
+	TerraformVersionsDir string   // Directory of installed terraform versions
+	Fs                   afero.Fs // Filesystem the steps are read from
+}
 
 func (info TerraformPlugin) Initialize(logger *logrus.Entry) {
 	logger.Info("Initializing runiac Terraform plugin")
 
+	flavor, err := terraform.ParseFlavor(info.Flavor)
+	if err != nil {
+		logger.WithError(err).Error("Error parsing terraform flavor")
+		return
+	}
+
 	// display terraform binary information
 	// disable checkpoints since we just want to print the version string alone
 	tfOptions := &terraform.Options{
 		TerraformDir: ".",
+		Flavor:       flavor,
 		EnvVars: map[string]string{
 			"CHECKPOINT_DISABLE": "true",
 		},
@@ -23,11 +40,79 @@ func (info TerraformPlugin) Initialize(logger *logrus.Entry) {
 		TimeBetweenRetries: 0,
 	}
 
-	terraformer := &terraform.Terraform{}
-	resp, err := terraformer.Version(tfOptions)
+	detected, version, err := terraform.DetectVersion(tfOptions)
 	if err != nil {
-		tfOptions.Logger.WithError(err).Error("Error running terraform version")
-	} else {
-		tfOptions.Logger.Info("Binary: ", resp)
+		tfOptions.Logger.WithError(err).Errorf("Error running %s version", flavor.Binary())
+		return
+	}
+
+	tfOptions.Logger.WithFields(logrus.Fields{
+		"flavor":  detected,
+		"version": version,
+	}).Infof("Binary: %s %s", detected, version)
+
+	if detected != flavor {
+		tfOptions.Logger.Warnf("Configured terraform flavor %s does not match the detected flavor %s", flavor, detected)
+	}
+}
+
+// PrepareRun warms the shared provider plugin cache with the providers required by all steps of the run
+func (info TerraformPlugin) PrepareRun(logger *logrus.Entry, steps []config.Step) {
+	if info.PluginCacheDir == "" {
+		return
+	}
+
+	logger = logger.WithField("terraform", "plugin-cache")
+
+	flavor, err := terraform.ParseFlavor(info.Flavor)
+	if err != nil {
+		logger.WithError(err).Error("Error parsing terraform flavor")
+		return
+	}
+
+	// terragrunt requires its own configuration, so the cache is warmed with the terraform it wraps
+	if flavor == terraform.TerragruntFlavor {
+		flavor = terraform.TerraformFlavor
 	}
+
+	options := terraform.Options{
+		Flavor:         flavor,
+		PluginCacheDir: info.PluginCacheDir,
+		EnvVars: map[string]string{
+			"CHECKPOINT_DISABLE": "true",
+		},
+		Logger:     logger,
+		NoColor:    true,
+		MaxRetries: 3,
+	}
+
+	retryableErrors, err := retry.CompileRetryableErrors(terraform.DefaultRetryableTerraformErrors)
+	if err != nil {
+		logger.WithError(err).Warn("Unable to warm plugin cache, providers will be installed by each step")
+		return
+	}
+	options.RetryableTerraformErrors = retryableErrors
+
+	// the cache layout is shared across versions, so the newest installed version is used
+	if info.TerraformVersionsDir != "" {
+		installed, err := InstalledTerraformVersions(info.Fs, info.TerraformVersionsDir, flavor.Binary())
+		if err == nil && len(installed) > 0 {
+			options.TerraformBinary = filepath.Join(info.TerraformVersionsDir, installed[0].Original(), flavor.Binary())
+		}
+	}
+
+	if err := WarmPluginCache(logger, info.Fs, steps, options); err != nil {
+		logger.WithError(err).Warn("Unable to warm plugin cache, providers will be installed by each step")
+	}
+}
+
+// IsStepDir returns whether the directory contains terraform or terragrunt configuration
+func (info TerraformPlugin) IsStepDir(fs afero.Fs, dir string) bool {
+	for _, pattern := range []string{"*.tf", "*.tf.json", "terragrunt.hcl"} {
+		if matches, _ := afero.Glob(fs, filepath.Join(dir, pattern)); len(matches) > 0 {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,416 @@
+package plugins_terraform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// ProviderRequirement is a provider source and version constraint declared in a step's required_providers
+type ProviderRequirement struct {
+	Source  string
+	Version string
+}
+
+var requiredProvidersSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "required_providers"},
+	},
+}
+
+var moduleFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "terraform"},
+		{Type: "module", LabelNames: []string{"name"}},
+	},
+}
+
+var moduleCallSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "source"},
+	},
+}
+
+var lockFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "provider", LabelNames: []string{"source"}},
+	},
+}
+
+var lockedProviderSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "version"},
+	},
+}
+
+// defaultRegistryHost is the registry implied by provider sources without a host
+const defaultRegistryHost = "registry.terraform.io/"
+
+// ParseRequiredProviders returns the providers declared in the required_providers blocks of the *.tf and *.tf.json
+// files within dir and the local modules it calls, and the provider versions pinned in its dependency lock file.
+// Providers declared without a source default to the hashicorp namespace.
+func ParseRequiredProviders(fs afero.Fs, dir string) ([]ProviderRequirement, error) {
+	requirements, err := parseModuleProviders(fs, dir, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := parseLockedProviders(fs, filepath.Join(dir, ".terraform.lock.hcl"))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(requirements, locked...), nil
+}
+
+// parseModuleProviders returns the required providers of the module within dir, followed by those of the local
+// modules it calls. Registry and remote modules are only known after init, so they are not followed.
+func parseModuleProviders(fs afero.Fs, dir string, visited map[string]bool) ([]ProviderRequirement, error) {
+	dir = filepath.Clean(dir)
+	if visited[dir] {
+		return nil, nil
+	}
+	visited[dir] = true
+
+	files := []string{}
+
+	for _, pattern := range []string{"*.tf", "*.tf.json"} {
+		matches, err := afero.Glob(fs, filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	requirements := []ProviderRequirement{}
+	modules := []string{}
+
+	for _, file := range files {
+		b, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return nil, err
+		}
+
+		f, diags := parseTerraformFile(b, file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+		}
+
+		content, _, diags := f.Body.PartialContent(moduleFileSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+		}
+
+		for _, block := range content.Blocks {
+			if block.Type == "module" {
+				source, err := parseLocalModuleSource(block)
+				if err != nil {
+					return nil, fmt.Errorf("unable to parse module %s in %s: %w", block.Labels[0], file, err)
+				}
+				if source != "" {
+					modules = append(modules, filepath.Join(dir, source))
+				}
+				continue
+			}
+
+			tfContent, _, diags := block.Body.PartialContent(requiredProvidersSchema)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+			}
+
+			for _, providersBlock := range tfContent.Blocks {
+				attrs, diags := providersBlock.Body.JustAttributes()
+				if diags.HasErrors() {
+					return nil, fmt.Errorf("unable to parse required_providers in %s: %s", file, diags.Error())
+				}
+
+				for name, attr := range attrs {
+					requirement, err := parseProviderRequirement(name, attr.Expr)
+					if err != nil {
+						return nil, fmt.Errorf("unable to parse required provider %s in %s: %w", name, file, err)
+					}
+					requirements = append(requirements, requirement)
+				}
+			}
+		}
+	}
+
+	for _, module := range modules {
+		r, err := parseModuleProviders(fs, module, visited)
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, r...)
+	}
+
+	return requirements, nil
+}
+
+// parseLocalModuleSource returns the source of a module call when it is a local path, otherwise an empty string
+func parseLocalModuleSource(block *hcl.Block) (string, error) {
+	content, _, diags := block.Body.PartialContent(moduleCallSchema)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	attr, ok := content.Attributes["source"]
+	if !ok {
+		return "", nil
+	}
+
+	source, err := staticString(attr.Expr)
+	if err != nil {
+		return "", fmt.Errorf("source: %w", err)
+	}
+
+	if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		return "", nil
+	}
+
+	return source, nil
+}
+
+// parseLockedProviders returns the provider versions pinned in a dependency lock file, which may not exist
+func parseLockedProviders(fs afero.Fs, file string) ([]ProviderRequirement, error) {
+	b, err := afero.ReadFile(fs, file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, diags := hclsyntax.ParseConfig(b, file, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+	}
+
+	content, _, diags := f.Body.PartialContent(lockFileSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+	}
+
+	requirements := []ProviderRequirement{}
+
+	for _, block := range content.Blocks {
+		providerContent, _, diags := block.Body.PartialContent(lockedProviderSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+		}
+
+		requirement := ProviderRequirement{Source: strings.TrimPrefix(block.Labels[0], defaultRegistryHost)}
+
+		if attr, ok := providerContent.Attributes["version"]; ok {
+			v, err := staticString(attr.Expr)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse locked provider %s in %s: %w", block.Labels[0], file, err)
+			}
+			requirement.Version = v
+		}
+
+		requirements = append(requirements, requirement)
+	}
+
+	return requirements, nil
+}
+
+// parseProviderRequirement reads the source and version of a required provider, either declared as an object or
+// as a legacy version string. Other attributes such as configuration_aliases are ignored.
+func parseProviderRequirement(name string, expr hcl.Expression) (ProviderRequirement, error) {
+	requirement := ProviderRequirement{
+		Source: fmt.Sprintf("hashicorp/%s", name),
+	}
+
+	pairs, diags := hcl.ExprMap(expr)
+	if diags.HasErrors() {
+		v, err := staticString(expr)
+		if err != nil {
+			return requirement, err
+		}
+		requirement.Version = v
+		return requirement, nil
+	}
+
+	for _, pair := range pairs {
+		key := hcl.ExprAsKeyword(pair.Key)
+		if key != "source" && key != "version" {
+			continue
+		}
+
+		v, err := staticString(pair.Value)
+		if err != nil {
+			return requirement, fmt.Errorf("%s: %w", key, err)
+		}
+
+		if key == "source" {
+			requirement.Source = v
+		} else {
+			requirement.Version = v
+		}
+	}
+
+	return requirement, nil
+}
+
+// providerWarmModules groups the provider requirements into as few root modules as possible. A module can only
+// declare one version constraint per provider, so a provider required with different constraints across steps is
+// spread over multiple modules.
+func providerWarmModules(requirements []ProviderRequirement) [][]ProviderRequirement {
+	constraints := map[string][]string{}
+
+	for _, r := range requirements {
+		if !contains(constraints[r.Source], r.Version) {
+			constraints[r.Source] = append(constraints[r.Source], r.Version)
+		}
+	}
+
+	sources := make([]string, 0, len(constraints))
+	for source, versions := range constraints {
+		// an unconstrained requirement is satisfied by any of the constrained ones
+		if len(versions) > 1 {
+			filtered := []string{}
+			for _, v := range versions {
+				if v != "" {
+					filtered = append(filtered, v)
+				}
+			}
+			versions = filtered
+		}
+
+		sort.Strings(versions)
+		constraints[source] = versions
+		sources = append(sources, source)
+	}
+
+	sort.Strings(sources)
+
+	modules := [][]ProviderRequirement{}
+
+	for i := 0; ; i++ {
+		module := []ProviderRequirement{}
+
+		for _, source := range sources {
+			if i < len(constraints[source]) {
+				module = append(module, ProviderRequirement{Source: source, Version: constraints[source][i]})
+			}
+		}
+
+		if len(module) == 0 {
+			return modules
+		}
+
+		modules = append(modules, module)
+	}
+}
+
+// formatWarmModule renders a root module that only declares the given providers
+func formatWarmModule(requirements []ProviderRequirement) string {
+	var sb strings.Builder
+
+	sb.WriteString("terraform {\n  required_providers {\n")
+
+	names := map[string]int{}
+
+	for _, r := range requirements {
+		name := r.Source[strings.LastIndex(r.Source, "/")+1:]
+
+		// local names must be unique within a module, e.g. for providers of the same type in different namespaces
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, names[name])
+		}
+
+		sb.WriteString(fmt.Sprintf("    %s = {\n      source = %q\n", name, r.Source))
+		if r.Version != "" {
+			sb.WriteString(fmt.Sprintf("      version = %q\n", r.Version))
+		}
+		sb.WriteString("    }\n")
+	}
+
+	sb.WriteString("  }\n}\n")
+
+	return sb.String()
+}
+
+// WarmPluginCache installs the union of the steps' required providers into the plugin cache once, so the concurrent
+// init of each step and region only links providers from the cache rather than downloading them into it.
+func WarmPluginCache(logger *logrus.Entry, fs afero.Fs, steps []config.Step, options terraform.Options) error {
+	requirements := []ProviderRequirement{}
+
+	for _, step := range steps {
+		dirs := []string{step.Dir}
+		if step.RegionalResourcesExist {
+			dirs = append(dirs, filepath.Join(step.Dir, "regional"))
+		}
+
+		for _, dir := range dirs {
+			r, err := ParseRequiredProviders(fs, dir)
+			if err != nil {
+				return err
+			}
+			requirements = append(requirements, r...)
+		}
+	}
+
+	modules := providerWarmModules(requirements)
+
+	if len(modules) == 0 {
+		logger.Info("No required providers declared, skipping plugin cache warming")
+		return nil
+	}
+
+	if err := os.MkdirAll(options.PluginCacheDir, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create plugin cache directory %s: %w", options.PluginCacheDir, err)
+	}
+
+	for i, module := range modules {
+		dir, err := ioutil.TempDir("", "runiac-plugin-cache-")
+		if err != nil {
+			return err
+		}
+
+		err = func() error {
+			defer os.RemoveAll(dir)
+
+			if err := ioutil.WriteFile(filepath.Join(dir, "versions.tf"), []byte(formatWarmModule(module)), 0644); err != nil {
+				return err
+			}
+
+			moduleOptions := options
+			moduleOptions.TerraformDir = dir
+			moduleOptions.EnvVars = map[string]string{}
+			for k, v := range options.EnvVars {
+				moduleOptions.EnvVars[k] = v
+			}
+			moduleOptions.Logger = logger.WithField("pluginCacheModule", i)
+
+			_, err := retryPhase(&moduleOptions, "init providers", func(attempt int) (string, error) {
+				return terraformer.InitProviders(&moduleOptions)
+			})
+			return err
+		}()
+
+		if err != nil {
+			return fmt.Errorf("unable to warm plugin cache: %w", err)
+		}
+	}
+
+	providers := 0
+	for _, module := range modules {
+		providers += len(module)
+	}
+
+	logger.Infof("Warmed plugin cache %s with %d provider version constraint(s)", options.PluginCacheDir, providers)
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package plugins_terraform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// initProvidersSpy records the root modules the plugin cache is warmed with
+type initProvidersSpy struct {
+	terraform.Terraform
+	modules *[]string
+	options *[]terraform.Options
+}
+
+func (s initProvidersSpy) InitProviders(options *terraform.Options) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(options.TerraformDir, "versions.tf"))
+	if err != nil {
+		return "", err
+	}
+
+	*s.modules = append(*s.modules, string(b))
+	*s.options = append(*s.options, *options)
+
+	return "", nil
+}
+
+func TestParseRequiredProviders_ShouldReadObjectAndLegacyRequirements(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	_ = afero.WriteFile(fs, "/step/versions.tf", []byte(`
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 3.0"
+      configuration_aliases = [aws.secondary]
+    }
+    google = "3.51.1"
+    datadog = {
+      source = "DataDog/datadog"
+    }
+  }
+}
+
+provider "aws" {
+  region = var.region
+}
+`), 0644)
+
+	requirements, err := ParseRequiredProviders(fs, "/step")
+
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ProviderRequirement{
+		{Source: "hashicorp/aws", Version: "~> 3.0"},
+		{Source: "hashicorp/google", Version: "3.51.1"},
+		{Source: "DataDog/datadog"},
+	}, requirements)
+}
+
+func TestParseRequiredProviders_ShouldReadJSONLocalModulesAndLockFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	_ = afero.WriteFile(fs, "/step/versions.tf.json", []byte(`{
+  "terraform": {"required_providers": {"aws": {"source": "hashicorp/aws", "version": "~> 4.0"}}},
+  "module": {
+    "network": {"source": "./modules/network"},
+    "dns": {"source": "terraform-aws-modules/route53/aws"}
+  }
+}`), 0644)
+	_ = afero.WriteFile(fs, "/step/modules/network/main.tf", []byte(`
+terraform {
+  required_providers {
+    random = {
+      source = "hashicorp/random"
+    }
+  }
+}
+
+module "self" {
+  source = "../network"
+}
+`), 0644)
+	_ = afero.WriteFile(fs, "/step/.terraform.lock.hcl", []byte(`
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "4.5.0"
+  constraints = "~> 4.0"
+  hashes = [
+    "h1:abc=",
+  ]
+}
+`), 0644)
+
+	requirements, err := ParseRequiredProviders(fs, "/step")
+
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ProviderRequirement{
+		{Source: "hashicorp/aws", Version: "~> 4.0"},
+		{Source: "hashicorp/random"},
+		{Source: "hashicorp/aws", Version: "4.5.0"},
+	}, requirements)
+}
+
+func TestParseRequiredProviders_ShouldRejectNonStringRequirements(t *testing.T) {
+	for _, requirement := range []string{"null", "3", `{ source = null }`, `{ source = "hashicorp/aws", version = 3 }`} {
+		fs := afero.NewMemMapFs()
+
+		_ = afero.WriteFile(fs, "/step/versions.tf", []byte("terraform {\n  required_providers {\n    aws = "+requirement+"\n  }\n}\n"), 0644)
+
+		_, err := ParseRequiredProviders(fs, "/step")
+		require.Error(t, err, requirement)
+	}
+}
+
+func TestProviderWarmModules_ShouldSpreadConflictingConstraints(t *testing.T) {
+	modules := providerWarmModules([]ProviderRequirement{
+		{Source: "hashicorp/aws", Version: "~> 3.0"},
+		{Source: "hashicorp/aws", Version: "~> 4.0"},
+		{Source: "hashicorp/aws", Version: "~> 3.0"},
+		{Source: "hashicorp/aws"},
+		{Source: "hashicorp/google"},
+	})
+
+	require.Equal(t, [][]ProviderRequirement{
+		{{Source: "hashicorp/aws", Version: "~> 3.0"}, {Source: "hashicorp/google"}},
+		{{Source: "hashicorp/aws", Version: "~> 4.0"}},
+	}, modules)
+}
+
+func TestFormatWarmModule_ShouldUseUniqueLocalNames(t *testing.T) {
+	module := formatWarmModule([]ProviderRequirement{
+		{Source: "hashicorp/random", Version: "3.0.0"},
+		{Source: "example/random"},
+	})
+
+	require.Equal(t, `terraform {
+  required_providers {
+    random = {
+      source = "hashicorp/random"
+      version = "3.0.0"
+    }
+    random_2 = {
+      source = "example/random"
+    }
+  }
+}
+`, module)
+}
+
+func TestWarmPluginCache_ShouldInitUnionOfStepAndRegionalRequirements(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	_ = afero.WriteFile(fs, "/tracks/a/step1_a/versions.tf", []byte(`
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 3.0"
+    }
+  }
+}
+`), 0644)
+	_ = afero.WriteFile(fs, "/tracks/a/step1_a/regional/versions.tf", []byte(`
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 4.0"
+    }
+  }
+}
+`), 0644)
+	_ = afero.WriteFile(fs, "/tracks/b/step1_b/versions.tf", []byte(`
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 3.0"
+    }
+  }
+}
+`), 0644)
+
+	cacheDir, err := ioutil.TempDir("", "runiac-plugin-cache-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	modules := []string{}
+	options := []terraform.Options{}
+
+	original := terraformer
+	terraformer = initProvidersSpy{modules: &modules, options: &options}
+	defer func() { terraformer = original }()
+
+	err = WarmPluginCache(logger, fs, []config.Step{
+		{Dir: "/tracks/a/step1_a", RegionalResourcesExist: true},
+		{Dir: "/tracks/b/step1_b"},
+	}, terraform.Options{PluginCacheDir: cacheDir})
+
+	require.NoError(t, err)
+	require.Len(t, modules, 2, "Should warm once per distinct constraint rather than once per step")
+	require.Contains(t, modules[0], `version = "~> 3.0"`)
+	require.Contains(t, modules[1], `version = "~> 4.0"`)
+
+	for _, o := range options {
+		require.Equal(t, cacheDir, o.PluginCacheDir)
+	}
+}
@@ -0,0 +1,101 @@
+package plugins_terraform
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+)
+
+// CheckStep checks the formatting of the execution's terraform files with terraform fmt -check and validates its
+// configuration with terraform validate. Validation initializes the directory without a backend in a separate data
+// directory, so the backend is never contacted and the execution's own initialization is untouched.
+func (stepper TerraformStepper) CheckStep(exec config.StepExecution) []config.PreflightIssue {
+	issues := []config.PreflightIssue{}
+
+	addIssue := func(check string, message string) []config.PreflightIssue {
+		issues = append(issues, config.PreflightIssue{
+			StepID:           exec.StepID,
+			RegionDeployType: exec.RegionDeployType,
+			Dir:              exec.Dir,
+			Check:            check,
+			Message:          message,
+		})
+		return issues
+	}
+
+	flavor, err := ResolveTerraformFlavor(exec)
+	if err != nil {
+		return addIssue("flavor", err.Error())
+	}
+
+	exec.TerraformFlavor = string(flavor)
+
+	exec.RunnerBinary, exec.RunnerVersion, err = ResolveTerraformBinary(exec)
+	if err != nil {
+		return addIssue("version", err.Error())
+	}
+
+	tfOptions, err := getCommonTfOptions2(exec)
+	if err != nil {
+		return addIssue("options", err.Error())
+	}
+
+	tfOptions.Logger = tfOptions.Logger.WithField("terraform", "preflight")
+
+	// terragrunt initializes the backend to validate, so terragrunt steps are only checked with the terraform it wraps
+	validate := true
+
+	if tfOptions.Flavor == terraform.TerragruntFlavor {
+		tfOptions.Flavor = terraform.TerraformFlavor
+		tfOptions.TerraformBinary = exec.RunnerBinary
+		delete(tfOptions.EnvVars, "TERRAGRUNT_TFPATH")
+		validate = false
+	}
+
+	out, err := terraformer.FmtCheck(tfOptions)
+	if err != nil && strings.TrimSpace(out) != "" {
+		addIssue("fmt", "files are not formatted canonically, run terraform fmt:\n"+out)
+	} else if err != nil {
+		addIssue("fmt", err.Error())
+	}
+
+	if !validate {
+		tfOptions.Logger.Info("Skipping terraform validate, terragrunt steps are validated during deployment")
+		return issues
+	}
+
+	dataDir, err := ioutil.TempDir("", "runiac-preflight-")
+	if err != nil {
+		return addIssue("validate", err.Error())
+	}
+
+	defer os.RemoveAll(dataDir)
+
+	tfOptions.EnvVars["TF_DATA_DIR"] = dataDir
+
+	out, err = retryPhase(tfOptions, "init", func(attempt int) (string, error) {
+		return terraformer.InitProviders(tfOptions)
+	})
+	if err != nil {
+		return addIssue("init", preflightMessage(out, err))
+	}
+
+	out, err = terraformer.Validate(tfOptions)
+	if err != nil {
+		addIssue("validate", preflightMessage(out, err))
+	}
+
+	return issues
+}
+
+// preflightMessage returns the output of a failed check, or its error when it has no output
+func preflightMessage(out string, err error) string {
+	if strings.TrimSpace(out) == "" {
+		return err.Error()
+	}
+
+	return out
+}
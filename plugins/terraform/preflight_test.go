@@ -0,0 +1,94 @@
+package plugins_terraform
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// preflightSpy fails the configured checks and records the data directory validation ran with
+type preflightSpy struct {
+	terraform.Terraform
+	unformatted string
+	invalid     string
+	dataDir     *string
+	validated   *bool
+}
+
+func (s preflightSpy) FmtCheck(options *terraform.Options) (string, error) {
+	if s.unformatted != "" {
+		return s.unformatted, errors.New("exit status 3")
+	}
+	return "", nil
+}
+
+func (s preflightSpy) InitProviders(options *terraform.Options) (string, error) {
+	*s.dataDir = options.EnvVars["TF_DATA_DIR"]
+	return "", nil
+}
+
+func (s preflightSpy) Validate(options *terraform.Options) (string, error) {
+	*s.validated = true
+	if s.invalid != "" {
+		return s.invalid, errors.New("exit status 1")
+	}
+	return "", nil
+}
+
+func TestCheckStep(t *testing.T) {
+	tests := map[string]struct {
+		flavor      string
+		unformatted string
+		invalid     string
+		checks      []string
+		validated   bool
+	}{
+		"valid":           {flavor: "terraform", checks: []string{}, validated: true},
+		"unformatted":     {flavor: "terraform", unformatted: "main.tf\n", checks: []string{"fmt"}, validated: true},
+		"invalid":         {flavor: "terraform", invalid: "Error: Unsupported argument", checks: []string{"validate"}, validated: true},
+		"both":            {flavor: "terraform", unformatted: "main.tf\n", invalid: "Error: Unsupported argument", checks: []string{"fmt", "validate"}, validated: true},
+		"terragrunt fmt":  {flavor: "terragrunt", unformatted: "main.tf\n", checks: []string{"fmt"}},
+		"terragrunt only": {flavor: "terragrunt", checks: []string{}},
+	}
+
+	original := terraformer
+	defer func() { terraformer = original }()
+
+	for name, test := range tests {
+		dataDir := ""
+		validated := false
+		terraformer = preflightSpy{unformatted: test.unformatted, invalid: test.invalid, dataDir: &dataDir, validated: &validated}
+
+		exec := config.StepExecution{
+			Fs:              afero.NewMemMapFs(),
+			Logger:          logger,
+			StepID:          "core/network",
+			Dir:             "tracks/core/step1_network",
+			TerraformFlavor: test.flavor,
+		}
+
+		issues := TerraformStepper{}.CheckStep(exec)
+
+		checks := []string{}
+		for _, issue := range issues {
+			require.Equal(t, "core/network", issue.StepID, name)
+			require.Equal(t, exec.Dir, issue.Dir, name)
+			checks = append(checks, issue.Check)
+		}
+
+		require.Equal(t, test.checks, checks, name)
+		require.Equal(t, test.validated, validated, name)
+
+		if test.validated {
+			require.NotEmpty(t, dataDir, name)
+
+			_, err := os.Stat(dataDir)
+			require.True(t, os.IsNotExist(err), "%s: the data directory should be removed", name)
+		}
+	}
+}
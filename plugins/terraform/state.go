@@ -0,0 +1,183 @@
+package plugins_terraform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+)
+
+// ListState initializes the execution and returns the resource addresses in its state
+func (stepper TerraformStepper) ListState(exec config.StepExecution) ([]string, error) {
+	tfOptions, err := initStateInDir(exec)
+	if err != nil {
+		return nil, err
+	}
+
+	return terraformer.StateList(tfOptions)
+}
+
+// ShowState initializes the execution and returns the attributes of a resource in its state
+func (stepper TerraformStepper) ShowState(exec config.StepExecution, address string) (string, error) {
+	tfOptions, err := initStateInDir(exec)
+	if err != nil {
+		return "", err
+	}
+
+	return terraformer.StateShow(tfOptions, address)
+}
+
+// RemoveState initializes the execution, backs up its state and removes the resources from it without destroying them
+func (stepper TerraformStepper) RemoveState(exec config.StepExecution, addresses []string) error {
+	tfOptions, err := initStateInDir(exec)
+	if err != nil {
+		return err
+	}
+
+	if _, err = backupState(exec, tfOptions); err != nil {
+		return err
+	}
+
+	_, err = terraformer.StateRm(tfOptions, addresses...)
+
+	return err
+}
+
+// MoveState backs up the states of both executions and moves a resource between them. Terraform can only move resources
+// between local state files, so moves across executions pull both states, move the resource between local copies and
+// push the results.
+func (stepper TerraformStepper) MoveState(source config.StepExecution, sourceAddress string, destination config.StepExecution, destinationAddress string) error {
+	sourceOptions, err := initStateInDir(source)
+	if err != nil {
+		return err
+	}
+
+	sourceState, err := backupState(source, sourceOptions)
+	if err != nil {
+		return err
+	}
+
+	if source.StepID == destination.StepID && source.RegionDeployType == destination.RegionDeployType && source.Region == destination.Region {
+		_, err = terraformer.StateMv(sourceOptions, sourceAddress, destinationAddress, "", "")
+		return err
+	}
+
+	destinationOptions, err := initStateInDir(destination)
+	if err != nil {
+		return err
+	}
+
+	destinationState, err := backupState(destination, destinationOptions)
+	if err != nil {
+		return err
+	}
+
+	// the local state files are read by terraform, so they are written to the OS file system
+	dir, err := ioutil.TempDir("", "runiac-state-mv-")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir)
+
+	sourceFile := filepath.Join(dir, "source.tfstate")
+	destinationFile := filepath.Join(dir, "destination.tfstate")
+
+	if err = ioutil.WriteFile(sourceFile, []byte(sourceState), 0600); err != nil {
+		return err
+	}
+
+	// terraform creates the destination state file when the destination has no state yet
+	if destinationState != "" {
+		if err = ioutil.WriteFile(destinationFile, []byte(destinationState), 0600); err != nil {
+			return err
+		}
+	}
+
+	mvOptions, err := localStateOptions(source, dir)
+	if err != nil {
+		return err
+	}
+
+	if _, err = terraformer.StateMv(mvOptions, sourceAddress, destinationAddress, sourceFile, destinationFile); err != nil {
+		return err
+	}
+
+	// the destination is pushed first, so a failed push leaves the resource in both states rather than in neither
+	if _, err = terraformer.StatePush(destinationOptions, destinationFile); err != nil {
+		return fmt.Errorf("unable to push the destination state, both states are unchanged: %w", err)
+	}
+
+	if _, err = terraformer.StatePush(sourceOptions, sourceFile); err != nil {
+		return fmt.Errorf("unable to push the source state, %s is in both states until it is removed from the source: %w", sourceAddress, err)
+	}
+
+	return nil
+}
+
+// initStateInDir initializes the execution's backend and workspace for state commands
+func initStateInDir(exec config.StepExecution) (*terraform.Options, error) {
+	tfOptions, _, err := initTerraformInDir(exec)
+	if err != nil {
+		return tfOptions, err
+	}
+
+	tfOptions.Logger = tfOptions.Logger.WithField("terraform", "state")
+
+	return tfOptions, nil
+}
+
+// localStateOptions returns options running terraform on local state files in dir. Terragrunt requires its
+// configuration in the working directory, so the terraform binary it wraps is run instead.
+func localStateOptions(exec config.StepExecution, dir string) (*terraform.Options, error) {
+	tfOptions, err := getCommonTfOptions2(exec)
+	if err != nil {
+		return tfOptions, err
+	}
+
+	tfOptions.TerraformDir = dir
+	tfOptions.Logger = tfOptions.Logger.WithField("terraform", "state")
+
+	if tfOptions.Flavor == terraform.TerragruntFlavor {
+		tfOptions.Flavor = terraform.TerraformFlavor
+		tfOptions.TerraformBinary = exec.RunnerBinary
+		delete(tfOptions.EnvVars, "TERRAGRUNT_TFPATH")
+	}
+
+	return tfOptions, nil
+}
+
+// stateBackupPath returns the backup of the execution's state taken by the run
+func stateBackupPath(exec config.StepExecution) string {
+	return filepath.Join(exec.StateBackupDir, exec.UniqueExternalExecutionID, executionKey(exec)+".tfstate")
+}
+
+// backupState pulls the execution's state and writes it to the state backup directory before it is mutated
+func backupState(exec config.StepExecution, tfOptions *terraform.Options) (string, error) {
+	if exec.StateBackupDir == "" {
+		return "", fmt.Errorf("no state backup directory configured, refusing to modify the state of step %s", exec.StepID)
+	}
+
+	state, err := terraformer.StatePull(tfOptions)
+	if err != nil {
+		return state, fmt.Errorf("unable to back up the state of step %s: %w", exec.StepID, err)
+	}
+
+	path := stateBackupPath(exec)
+
+	if err = exec.Fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return state, err
+	}
+
+	if err = afero.WriteFile(exec.Fs, path, []byte(state), 0600); err != nil {
+		return state, err
+	}
+
+	exec.Logger.Infof("Backed up the state of step %s to %s", exec.StepID, path)
+
+	return state, nil
+}
@@ -0,0 +1,179 @@
+package plugins_terraform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// stateSpy records the state commands run and serves the states of the stub executions by directory
+type stateSpy struct {
+	terraform.Terraform
+	states map[string]string
+	calls  *[]string
+}
+
+func (s stateSpy) Init(options *terraform.Options) (string, error) {
+	return "", nil
+}
+
+func (s stateSpy) WorkspaceSelect(options *terraform.Options, name string) (string, error) {
+	return "", nil
+}
+
+func (s stateSpy) StatePull(options *terraform.Options) (string, error) {
+	*s.calls = append(*s.calls, fmt.Sprintf("pull %s", options.TerraformDir))
+	return s.states[options.TerraformDir], nil
+}
+
+func (s stateSpy) StatePush(options *terraform.Options, stateFile string) (string, error) {
+	*s.calls = append(*s.calls, fmt.Sprintf("push %s %s", options.TerraformDir, filepath.Base(stateFile)))
+	return "", nil
+}
+
+func (s stateSpy) StateMv(options *terraform.Options, source string, destination string, stateFile string, stateOutFile string) (string, error) {
+	b, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		return "", err
+	}
+
+	*s.calls = append(*s.calls, fmt.Sprintf("mv %s %s %s %s %s", source, destination, filepath.Base(stateFile), filepath.Base(stateOutFile), b))
+	return "", nil
+}
+
+func (s stateSpy) StateRm(options *terraform.Options, addresses ...string) (string, error) {
+	*s.calls = append(*s.calls, fmt.Sprintf("rm %s %v", options.TerraformDir, addresses))
+	return "", nil
+}
+
+func stubStateExecution(fs afero.Fs, stepName string) config.StepExecution {
+	return config.StepExecution{
+		Fs:                        fs,
+		Logger:                    logger,
+		Namespace:                 "ns",
+		TrackName:                 "default",
+		StepName:                  stepName,
+		StepID:                    "default/" + stepName,
+		Dir:                       "/steps/" + stepName,
+		Region:                    "us-east-1",
+		StateBackupDir:            "/backups",
+		UniqueExternalExecutionID: "run-1",
+	}
+}
+
+func stubStateSpy() (stateSpy, *[]string) {
+	calls := []string{}
+	return stateSpy{
+		states: map[string]string{
+			"/steps/network": "network-state",
+			"/steps/dns":     "dns-state",
+		},
+		calls: &calls,
+	}, &calls
+}
+
+func TestMoveState_ShouldBackUpBothStatesAndPushDestinationFirst(t *testing.T) {
+	original := terraformer
+	defer func() { terraformer = original }()
+
+	spy, calls := stubStateSpy()
+	terraformer = spy
+
+	fs := afero.NewMemMapFs()
+	source := stubStateExecution(fs, "network")
+	destination := stubStateExecution(fs, "dns")
+
+	err := TerraformStepper{}.MoveState(source, "aws_route53_zone.main", destination, "aws_route53_zone.main")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"pull /steps/network",
+		"pull /steps/dns",
+		"mv aws_route53_zone.main aws_route53_zone.main source.tfstate destination.tfstate network-state",
+		"push /steps/dns destination.tfstate",
+		"push /steps/network source.tfstate",
+	}, *calls)
+
+	for path, expected := range map[string]string{
+		stateBackupPath(source):      "network-state",
+		stateBackupPath(destination): "dns-state",
+	} {
+		b, err := afero.ReadFile(fs, path)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(b))
+	}
+}
+
+func TestMoveState_ShouldMoveWithinStateOfSameExecution(t *testing.T) {
+	original := terraformer
+	defer func() { terraformer = original }()
+
+	var mvStateFile string
+	spy, calls := stubStateSpy()
+	terraformer = sameStateMvSpy{stateSpy: spy, stateFile: &mvStateFile}
+
+	fs := afero.NewMemMapFs()
+	exec := stubStateExecution(fs, "network")
+
+	err := TerraformStepper{}.MoveState(exec, "aws_vpc.main", exec, "aws_vpc.primary")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"pull /steps/network", "mv aws_vpc.main aws_vpc.primary"}, *calls)
+	require.Empty(t, mvStateFile)
+
+	exists, err := afero.Exists(fs, stateBackupPath(exec))
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+// sameStateMvSpy records moves within the workspace's state
+type sameStateMvSpy struct {
+	stateSpy
+	stateFile *string
+}
+
+func (s sameStateMvSpy) StateMv(options *terraform.Options, source string, destination string, stateFile string, stateOutFile string) (string, error) {
+	*s.stateFile = stateFile + stateOutFile
+	*s.calls = append(*s.calls, fmt.Sprintf("mv %s %s", source, destination))
+	return "", nil
+}
+
+func TestRemoveState_ShouldBackUpStateBeforeRemoving(t *testing.T) {
+	original := terraformer
+	defer func() { terraformer = original }()
+
+	spy, calls := stubStateSpy()
+	terraformer = spy
+
+	exec := stubStateExecution(afero.NewMemMapFs(), "network")
+
+	err := TerraformStepper{}.RemoveState(exec, []string{"aws_vpc.main", "aws_subnet.a"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"pull /steps/network", "rm /steps/network [aws_vpc.main aws_subnet.a]"}, *calls)
+
+	b, err := afero.ReadFile(exec.Fs, "/backups/run-1/ns-default-network-primary-us-east-1.tfstate")
+	require.NoError(t, err)
+	require.Equal(t, "network-state", string(b))
+}
+
+func TestRemoveState_ShouldRefuseWithoutBackupDirectory(t *testing.T) {
+	original := terraformer
+	defer func() { terraformer = original }()
+
+	spy, calls := stubStateSpy()
+	terraformer = spy
+
+	exec := stubStateExecution(afero.NewMemMapFs(), "network")
+	exec.StateBackupDir = ""
+
+	err := TerraformStepper{}.RemoveState(exec, []string{"aws_vpc.main"})
+	require.Error(t, err)
+	require.Empty(t, *calls)
+}
@@ -0,0 +1,249 @@
+package plugins_terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// maxLockHolders is the number of previous runs recorded in the lock ledger of an execution
+const maxLockHolders = 10
+
+// lockHeartbeatInterval is how often a run refreshes its heartbeat in the lock ledger while it executes terraform
+var lockHeartbeatInterval = time.Minute
+
+// lockHolderTimeout is how long after its last heartbeat an unfinished run is considered ended, e.g. killed
+var lockHolderTimeout = 5 * time.Minute
+
+// LockHolder records that a run executed terraform for a step and region, and which lock holder identity terraform
+// reports for it. Terraform identifies lock holders as user@hostname.
+type LockHolder struct {
+	RunID     string    `json:"run_id"`
+	Who       string    `json:"who"`
+	Started   time.Time `json:"started"`
+	Heartbeat time.Time `json:"heartbeat,omitempty"` // Refreshed while the run executes terraform for the step and region
+	Finished  bool      `json:"finished,omitempty"`  // The run exited the execution, releasing its locks
+}
+
+// ended returns whether the run is no longer executing, either because it finished or stopped refreshing its heartbeat
+func (h LockHolder) ended(now time.Time) bool {
+	if h.Finished {
+		return true
+	}
+
+	last := h.Heartbeat
+	if last.IsZero() {
+		last = h.Started
+	}
+
+	return now.Sub(last) > lockHolderTimeout
+}
+
+// currentLockHolder returns the lock holder identity terraform reports for locks acquired by this process
+var currentLockHolder = func() string {
+	username := ""
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	host, _ := os.Hostname()
+
+	return fmt.Sprintf("%s@%s", username, host)
+}
+
+// lockLedgerPath returns the ledger file of the execution's namespace, step, deploy type and region
+func lockLedgerPath(exec config.StepExecution) string {
+	return filepath.Join(exec.LockLedgerDir, executionKey(exec)+".json")
+}
+
+// executionKey identifies the execution's namespace, step, deploy type and region in file names
+func executionKey(exec config.StepExecution) string {
+	return sanitizeStateKey(fmt.Sprintf("%s-%s-%s-%s-%s", exec.Namespace, exec.TrackName, exec.StepName, exec.RegionDeployType, exec.Region), "-")
+}
+
+// readLockHolders returns the lock holders recorded for the execution's namespace, step and region, oldest first
+func readLockHolders(exec config.StepExecution) ([]LockHolder, error) {
+	holders := []LockHolder{}
+
+	b, err := afero.ReadFile(exec.Fs, lockLedgerPath(exec))
+	if os.IsNotExist(err) {
+		return holders, nil
+	} else if err != nil {
+		return holders, err
+	}
+
+	err = json.Unmarshal(b, &holders)
+
+	return holders, err
+}
+
+// writeLockHolders replaces the execution's lock ledger, keeping the most recent holders
+func writeLockHolders(exec config.StepExecution, holders []LockHolder) error {
+	if len(holders) > maxLockHolders {
+		holders = holders[len(holders)-maxLockHolders:]
+	}
+
+	b, err := json.Marshal(holders)
+	if err != nil {
+		return err
+	}
+
+	if err = exec.Fs.MkdirAll(exec.LockLedgerDir, 0755); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(exec.Fs, lockLedgerPath(exec), b, 0644)
+}
+
+// recordLockHolder appends the current run to the execution's lock ledger and returns the previously recorded holders
+// along with the current run's holder
+func recordLockHolder(exec config.StepExecution) ([]LockHolder, LockHolder, error) {
+	now := time.Now().UTC()
+
+	current := LockHolder{
+		RunID:     exec.UniqueExternalExecutionID,
+		Who:       currentLockHolder(),
+		Started:   now,
+		Heartbeat: now,
+	}
+
+	previous, err := readLockHolders(exec)
+	if err != nil {
+		return previous, current, err
+	}
+
+	return previous, current, writeLockHolders(exec, append(previous, current))
+}
+
+// updateLockHolder updates the current run's latest entry in the execution's lock ledger
+func updateLockHolder(exec config.StepExecution, current LockHolder, update func(holder *LockHolder)) error {
+	holders, err := readLockHolders(exec)
+	if err != nil {
+		return err
+	}
+
+	for i := len(holders) - 1; i >= 0; i-- {
+		if holders[i].RunID == current.RunID && holders[i].Started.Equal(current.Started) {
+			update(&holders[i])
+			return writeLockHolders(exec, holders)
+		}
+	}
+
+	return nil
+}
+
+// keepLockHolderAlive refreshes the current run's heartbeat in the execution's lock ledger until the returned function
+// is called, which records that the run finished the execution
+func keepLockHolderAlive(exec config.StepExecution, current LockHolder, logger *logrus.Entry) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(lockHeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := updateLockHolder(exec, current, func(holder *LockHolder) {
+					holder.Heartbeat = time.Now().UTC()
+				})
+				if err != nil {
+					logger.WithError(err).Warn("Unable to refresh state lock holder heartbeat")
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+
+		err := updateLockHolder(exec, current, func(holder *LockHolder) {
+			holder.Finished = true
+		})
+		if err != nil {
+			logger.WithError(err).Warn("Unable to record state lock holder as finished")
+		}
+	}
+}
+
+// lockHeldByPreviousRun returns the previous run of the same namespace, step and region holding the lock. The lock
+// must have been created by the run's lock holder identity after that run started and before the current run started,
+// and the run must have ended without finishing the execution, e.g. because it was killed. Locks of runs that may
+// still be executing are never matched.
+func lockHeldByPreviousRun(previous []LockHolder, lock terraform.LockInfo, current LockHolder, now time.Time) (LockHolder, bool) {
+	if lock.ID == "" || lock.Who == "" || lock.Created.IsZero() || !lock.Created.Before(current.Started) {
+		return LockHolder{}, false
+	}
+
+	for i := len(previous) - 1; i >= 0; i-- {
+		holder := previous[i]
+
+		if holder.RunID == current.RunID || holder.Who != lock.Who || lock.Created.Before(holder.Started) {
+			continue
+		}
+
+		// the most recent run that could have created the lock owns it
+		if holder.Finished || !holder.ended(now) {
+			return LockHolder{}, false
+		}
+
+		return holder, true
+	}
+
+	return LockHolder{}, false
+}
+
+// handleStateLock reports a state lock error in the step output. When force unlock is enabled and the lock is held
+// by a previous run of the same step and region, the lock is force unlocked and true is returned to retry the phase.
+func handleStateLock(exec config.StepExecution, options *terraform.Options, out string, previous []LockHolder, current LockHolder, output *config.StepOutput) bool {
+	lock, ok := terraform.ParseLockInfo(terraform.UIOutputText(out))
+	if !ok {
+		return false
+	}
+
+	output.StateLock = &config.StateLock{
+		ID:        lock.ID,
+		Path:      lock.Path,
+		Operation: lock.Operation,
+		Who:       lock.Who,
+		Created:   lock.Created,
+	}
+
+	logger := options.Logger.WithField("lockID", lock.ID).WithField("lockWho", lock.Who)
+	logger.Errorf("State is locked by %s since %s for %s", lock.Who, lock.Created, lock.Operation)
+
+	if !exec.ForceUnlock {
+		return false
+	}
+
+	holder, ok := lockHeldByPreviousRun(previous, lock, current, time.Now().UTC())
+	if !ok {
+		logger.Warn("Not force unlocking, the lock holder does not match a previous run of this step and region that ended without finishing")
+		return false
+	}
+
+	logger.Warnf("Force unlocking state lock held by previous run %s", holder.RunID)
+
+	if _, err := terraformer.ForceUnlock(options, lock.ID); err != nil {
+		logger.WithError(err).Error("Error during terraform force-unlock")
+		return false
+	}
+
+	output.StateLock.ForceUnlocked = true
+
+	return true
+}
@@ -0,0 +1,197 @@
+package plugins_terraform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// forceUnlockSpy records the lock IDs that are force unlocked
+type forceUnlockSpy struct {
+	terraform.Terraform
+	unlocked *[]string
+}
+
+func (s forceUnlockSpy) ForceUnlock(options *terraform.Options, lockID string) (string, error) {
+	*s.unlocked = append(*s.unlocked, lockID)
+	return "", nil
+}
+
+const stubLockOutput = `Error: Error acquiring the state lock
+
+Lock Info:
+  ID:        stub-lock-id
+  Path:      stub/path
+  Operation: OperationTypeApply
+  Who:       root@killed-container
+  Version:   1.3.9
+  Created:   2023-03-01 12:30:45.123456789 +0000 UTC
+  Info:
+`
+
+func stubLockExecution() config.StepExecution {
+	return config.StepExecution{
+		Fs:                        afero.NewMemMapFs(),
+		Logger:                    logger,
+		Namespace:                 "ns",
+		TrackName:                 "default",
+		StepName:                  "network",
+		Region:                    "us-east-1",
+		LockLedgerDir:             "/locks",
+		UniqueExternalExecutionID: "current-run",
+		ForceUnlock:               true,
+	}
+}
+
+func TestRecordLockHolder_ShouldReturnPreviousHoldersOfSameStepAndRegion(t *testing.T) {
+	original := currentLockHolder
+	defer func() { currentLockHolder = original }()
+
+	exec := stubLockExecution()
+	exec.UniqueExternalExecutionID = "run-1"
+	currentLockHolder = func() string { return "root@container-1" }
+
+	previous, current, err := recordLockHolder(exec)
+	require.NoError(t, err)
+	require.Empty(t, previous)
+	require.Equal(t, "run-1", current.RunID)
+
+	exec.UniqueExternalExecutionID = "run-2"
+	currentLockHolder = func() string { return "root@container-2" }
+
+	previous, _, err = recordLockHolder(exec)
+	require.NoError(t, err)
+	require.Len(t, previous, 1)
+	require.Equal(t, "run-1", previous[0].RunID)
+	require.Equal(t, "root@container-1", previous[0].Who)
+
+	other := exec
+	other.Region = "us-west-2"
+
+	previous, _, err = recordLockHolder(other)
+	require.NoError(t, err)
+	require.Empty(t, previous, "Should not share holders across regions")
+}
+
+func TestLockHeldByPreviousRun(t *testing.T) {
+	created := time.Date(2023, 3, 1, 12, 30, 45, 0, time.UTC)
+	lock := terraform.LockInfo{ID: "id", Who: "root@killed-container", Created: created}
+	now := created.Add(time.Hour)
+	current := LockHolder{RunID: "current-run", Who: "root@new-container", Started: now}
+
+	tests := map[string]struct {
+		previous []LockHolder
+		lock     terraform.LockInfo
+		expected bool
+	}{
+		"killed previous run holds the lock": {
+			previous: []LockHolder{{RunID: "previous-run", Who: "root@killed-container", Started: created.Add(-time.Minute), Heartbeat: created}},
+			expected: true,
+		},
+		"previous run is still executing": {
+			previous: []LockHolder{{RunID: "previous-run", Who: "root@killed-container", Started: created.Add(-time.Minute), Heartbeat: now.Add(-time.Minute)}},
+		},
+		"previous run finished": {
+			previous: []LockHolder{{RunID: "previous-run", Who: "root@killed-container", Started: created.Add(-time.Minute), Heartbeat: created, Finished: true}},
+		},
+		"latest run of the holder is still executing": {
+			previous: []LockHolder{
+				{RunID: "killed-run", Who: "root@killed-container", Started: created.Add(-time.Hour), Heartbeat: created.Add(-time.Hour)},
+				{RunID: "concurrent-run", Who: "root@killed-container", Started: created.Add(-time.Minute), Heartbeat: now},
+			},
+		},
+		"lock holder is unknown": {
+			previous: []LockHolder{{RunID: "previous-run", Who: "root@other-container", Started: created.Add(-time.Minute)}},
+		},
+		"lock was created before the run started": {
+			previous: []LockHolder{{RunID: "previous-run", Who: "root@killed-container", Started: created.Add(time.Minute)}},
+		},
+		"lock is held by the current run": {
+			previous: []LockHolder{{RunID: "current-run", Who: "root@killed-container", Started: created.Add(-time.Minute)}},
+		},
+		"lock was created after the current run started": {
+			previous: []LockHolder{{RunID: "previous-run", Who: "root@killed-container", Started: created.Add(-time.Minute)}},
+			lock:     terraform.LockInfo{ID: "id", Who: "root@killed-container", Created: now.Add(time.Second)},
+		},
+		"lock creation time is unknown": {
+			previous: []LockHolder{{RunID: "previous-run", Who: "root@killed-container", Started: created.Add(-time.Minute)}},
+			lock:     terraform.LockInfo{ID: "id", Who: "root@killed-container"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := lock
+			if test.lock.ID != "" {
+				l = test.lock
+			}
+
+			_, ok := lockHeldByPreviousRun(test.previous, l, current, now)
+			require.Equal(t, test.expected, ok)
+		})
+	}
+}
+
+func TestKeepLockHolderAlive_ShouldRecordHeartbeatsAndFinish(t *testing.T) {
+	original := lockHeartbeatInterval
+	lockHeartbeatInterval = time.Millisecond
+	defer func() { lockHeartbeatInterval = original }()
+
+	exec := stubLockExecution()
+
+	_, current, err := recordLockHolder(exec)
+	require.NoError(t, err)
+
+	stop := keepLockHolderAlive(exec, current, logger)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	holders, err := readLockHolders(exec)
+	require.NoError(t, err)
+	require.Len(t, holders, 1)
+	require.True(t, holders[0].Heartbeat.After(current.Heartbeat))
+	require.True(t, holders[0].Finished)
+}
+
+func TestHandleStateLock_ShouldForceUnlockOnlyLocksOfPreviousRuns(t *testing.T) {
+	unlocked := []string{}
+
+	original := terraformer
+	terraformer = forceUnlockSpy{unlocked: &unlocked}
+	defer func() { terraformer = original }()
+
+	options := &terraform.Options{Logger: logger}
+	previous := []LockHolder{{RunID: "previous-run", Who: "root@killed-container", Started: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)}}
+	current := LockHolder{RunID: "current-run", Who: "root@new-container", Started: time.Now().UTC()}
+
+	// opt-in is required
+	exec := stubLockExecution()
+	exec.ForceUnlock = false
+	output := config.StepOutput{}
+
+	require.False(t, handleStateLock(exec, options, stubLockOutput, previous, current, &output))
+	require.Equal(t, "stub-lock-id", output.StateLock.ID)
+	require.Equal(t, "root@killed-container", output.StateLock.Who)
+	require.Empty(t, unlocked)
+
+	// unknown holders are never unlocked
+	output = config.StepOutput{}
+	require.False(t, handleStateLock(stubLockExecution(), options, stubLockOutput, nil, current, &output))
+	require.NotNil(t, output.StateLock)
+	require.Empty(t, unlocked)
+
+	// a previous run of the same step and region is unlocked
+	output = config.StepOutput{}
+	require.True(t, handleStateLock(stubLockExecution(), options, stubLockOutput, previous, current, &output))
+	require.True(t, output.StateLock.ForceUnlocked)
+	require.Equal(t, []string{"stub-lock-id"}, unlocked)
+
+	// other errors are not state locks
+	output = config.StepOutput{}
+	require.False(t, handleStateLock(stubLockExecution(), options, "Error: Invalid reference", previous, current, &output))
+	require.Nil(t, output.StateLock)
+}
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/pkg/logging"
 	"github.com/optum/runiac/pkg/retry"
 	"github.com/optum/runiac/pkg/shell"
 	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -20,10 +22,32 @@ type TerraformStepper struct{}
 var terraformer terraform.Terraformer = terraform.Terraform{}
 
 func (stepper TerraformStepper) PreExecute(exec config.StepExecution) (config.StepExecution, error) {
-	HandleDeployOverrides(exec.Logger, exec.Dir, exec.DeploymentRing)
+	exec.Overrides = HandleOverrides(exec, exec.SelfDestroy)
 
-	if exec.SelfDestroy {
-		HandleDestroyOverrides(exec.Logger, exec.Dir, exec.DeploymentRing)
+	flavor, err := ResolveTerraformFlavor(exec)
+
+	if err != nil {
+		return exec, err
+	}
+
+	exec.TerraformFlavor = string(flavor)
+
+	binary, version, err := ResolveTerraformBinary(exec)
+
+	if err != nil {
+		return exec, err
+	}
+
+	if binary != "" {
+		exec.Logger.Infof("Using %s %s (%s)", flavor, version, binary)
+	}
+
+	exec.RunnerBinary = binary
+	exec.RunnerVersion = version
+
+	if exec.TerraformJSONUI && !SupportsJSONUI(version) {
+		exec.Logger.Infof("Terraform %s does not support the machine readable UI, plan and apply output is logged as text", version)
+		exec.TerraformJSONUI = false
 	}
 
 	return exec, nil
@@ -41,7 +65,7 @@ func (stepper TerraformStepper) ExecuteStep(exec config.StepExecution) config.St
 
 // ExecuteStepTests executes the tests for a step
 func (stepper TerraformStepper) ExecuteStepTests(exec config.StepExecution) (output config.StepTestOutput) {
-	HandleDeployOverrides(exec.Logger, exec.Dir, exec.DeploymentRing)
+	HandleOverrides(exec, false)
 
 	envVars := map[string]string{}
 
@@ -87,6 +111,8 @@ func (stepper TerraformStepper) ExecuteStepTests(exec config.StepExecution) (out
 	return
 }
 
+// GetTerraformCLIVars returns the variables passed to terraform as -var arguments. Command lines are logged,
+// so these must never include step parameters or previous step outputs, which are passed via GetTerraformEnvVars
 func GetTerraformCLIVars(exec config.StepExecution) map[string]interface{} {
 	vars := map[string]interface{}{
 		"runiac_environment": exec.Environment,
@@ -125,28 +151,117 @@ func GetTerraformEnvVars(exec config.StepExecution) map[string]string {
 	return output
 }
 
+// GetTerraformVarFiles returns the var files within the step's vars directory that apply to the execution, relative to
+// the execution directory. Files are ordered from least to most specific, terraform gives precedence to later files:
+// common, environment, deployment ring and region. Regional executions read the vars directory of the step's regional directory.
+func GetTerraformVarFiles(exec config.StepExecution) []string {
+	candidates := []string{
+		"common.tfvars",
+		fmt.Sprintf("%s.tfvars", exec.Environment),
+		fmt.Sprintf("ring_%s.tfvars", strings.ToLower(exec.DeploymentRing)),
+		fmt.Sprintf("%s.tfvars", exec.Region),
+	}
+
+	varFiles := []string{}
+
+	for _, candidate := range candidates {
+		varFile := filepath.Join("vars", candidate)
+
+		if contains(varFiles, varFile) {
+			continue
+		}
+
+		if exists, _ := afero.Exists(exec.Fs, filepath.Join(exec.Dir, varFile)); exists {
+			varFiles = append(varFiles, varFile)
+		}
+	}
+
+	return varFiles
+}
+
+// HandleOverrides copies the override configurations that apply to the execution into its working directory,
+// returning the applied override files in order of precedence. Terraform merges override files in lexical order of
+// their names, so the scoped overrides are copied with a prefix placing them after the deployment ring's.
+func HandleOverrides(exec config.StepExecution, destroy bool) []string {
+	applied := []string{}
+
+	if destroy {
+		applied = append(applied, HandleDestroyOverrides(exec.Logger, exec.Dir, exec.DeploymentRing)...)
+		applied = append(applied, handleScopedOverrides(exec, "destroy_")...)
+	}
+
+	applied = append(applied, HandleDeployOverrides(exec.Logger, exec.Dir, exec.DeploymentRing)...)
+	applied = append(applied, handleScopedOverrides(exec, "")...)
+
+	return applied
+}
+
 // HandleDeployOverrides copy deploy override configurations into the
 // execution working directory
 func HandleDeployOverrides(logger *logrus.Entry, execDir string,
-	deploymentRing string) {
+	deploymentRing string) []string {
 	overrideFile := "override.tf"
 	ringOverrideFile := fmt.Sprintf("ring_%s_override.tf",
 		strings.ToLower(deploymentRing))
 
-	handleOverride(logger, execDir, overrideFile)
-	handleOverride(logger, execDir, ringOverrideFile)
+	applied := []string{}
+
+	for _, fileName := range []string{overrideFile, ringOverrideFile} {
+		if handleOverride(logger, execDir, fileName) {
+			applied = append(applied, filepath.Join("override", fileName))
+		}
+	}
+
+	return applied
 }
 
 // HandleDestroyOverrides copy destroy override configurations into the
 // execution working directory
 func HandleDestroyOverrides(logger *logrus.Entry, execDir string,
-	deploymentRing string) {
+	deploymentRing string) []string {
 	destroyOverrideFile := "destroy_override.tf"
 	destroyRingOverrideFile := fmt.Sprintf("destroy_ring_%s_override.tf",
 		strings.ToLower(deploymentRing))
 
-	handleOverride(logger, execDir, destroyOverrideFile)
-	handleOverride(logger, execDir, destroyRingOverrideFile)
+	applied := []string{}
+
+	for _, fileName := range []string{destroyOverrideFile, destroyRingOverrideFile} {
+		if handleOverride(logger, execDir, fileName) {
+			applied = append(applied, filepath.Join("override", fileName))
+		}
+	}
+
+	return applied
+}
+
+// handleScopedOverrides copies the environment, region and account override configurations, from least to most
+// specific, e.g. env_prod_override.tf is copied to runiac_1_env_prod_override.tf
+func handleScopedOverrides(exec config.StepExecution, prefix string) []string {
+	scopes := []struct {
+		Name  string
+		Value string
+	}{
+		{"env", exec.Environment},
+		{"region", exec.Region},
+		{"account", exec.AccountID},
+	}
+
+	applied := []string{}
+
+	for i, scope := range scopes {
+		if scope.Value == "" {
+			continue
+		}
+
+		fileName := fmt.Sprintf("%s%s_%s_override.tf", prefix, scope.Name, strings.ToLower(scope.Value))
+		dstName := fmt.Sprintf("%sruniac_%d_%s_%s_override.tf", prefix, i+1, scope.Name, strings.ToLower(scope.Value))
+
+		if copyOverride(exec.Logger, exec.Dir, fileName, dstName) {
+			applied = append(applied, filepath.Join("override", fileName))
+		}
+	}
+
+	return applied
 }
 
 func contains(s []string, e string) bool {
@@ -158,18 +273,32 @@ func contains(s []string, e string) bool {
 	return false
 }
 
-func handleOverride(logger *logrus.Entry, execDir string, fileName string) {
-	src := filepath.Join(execDir, "override", fileName)
-	dst := filepath.Join(execDir, fileName)
+func handleOverride(logger *logrus.Entry, execDir string, fileName string) bool {
+	return copyOverride(logger, execDir, fileName, fileName)
+}
 
-	logger.Infof("Attempting to copy %s to %s", src, dst)
+// copyOverride copies an override file from the step's override directory into the execution working directory,
+// returning whether it was copied
+func copyOverride(logger *logrus.Entry, execDir string, fileName string, dstName string) bool {
+	src := filepath.Join(execDir, "override", fileName)
+	dst := filepath.Join(execDir, dstName)
 
 	err := CopyFile(src, dst)
 
-	if err != nil && !os.IsNotExist(err) {
+	if os.IsNotExist(err) {
+		logger.Debugf("No override %s", src)
+		return false
+	}
+
+	if err != nil {
 		logger.WithError(err).Errorf(
 			"Overrides were not successfully set targeting %s", fileName)
+		return false
 	}
+
+	logger.Infof("Applied override %s", src)
+
+	return true
 }
 
 // executeTerraformInDir is a helper function for executing terraform in a specified directory
@@ -178,224 +307,382 @@ var executeTerraformInDir = func(exec config.StepExecution, destroy bool) (outpu
 	output.Region = exec.Region
 	output.StepName = exec.StepName
 	output.Status = config.Fail // assume failure
+	output.RunnerVersion = exec.RunnerVersion
+	output.Targets = exec.Targets
+	output.Replaces = exec.Replaces
+	output.Overrides = exec.Overrides
 	var resp string
 	var tfOptions *terraform.Options
 
 	// terraform init
-	tfOptions, output.Err = getCommonTfOptions2(exec)
+	tfOptions, _, output.Err = initTerraformInDir(exec)
 
 	if output.Err != nil {
-		tfOptions.Logger.WithError(output.Err).Error("unable to retrieve credentials for terraform init")
 		return
 	}
 
-	tfOptions.BackendConfig = GetBackendConfig(exec, ParseTFBackend).Config
-	tfOptions.Logger = tfOptions.Logger.WithField("terraform", "init")
-	resp, output.Err = terraformer.Init(tfOptions)
+	var err error
 
-	if output.Err != nil {
-		tfOptions.Logger.WithError(output.Err).Error("Error during terraform init")
-		return
+	// record this run as a lock holder, so a later run can identify locks left behind if it is killed
+	var previousHolders []LockHolder
+	var currentHolder LockHolder
+
+	if exec.LockLedgerDir != "" {
+		previousHolders, currentHolder, err = recordLockHolder(exec)
+
+		if err != nil {
+			tfOptions.Logger.WithError(err).Warn("Unable to record state lock holder")
+		} else {
+			defer keepLockHolderAlive(exec, currentHolder, tfOptions.Logger)()
+		}
 	}
 
-	tfOptions.Logger = tfOptions.Logger.WithField("terraform", "workspace")
+	// lockedPhase runs a phase that acquires the state lock, retrying it once when a stale lock was force unlocked
+	lockedPhase := func(options *terraform.Options, phase string, action func(attempt int) (string, error)) (string, error) {
+		out, err := retryPhase(options, phase, action)
 
-	workspace := fmt.Sprintf("%s-%s", exec.RegionDeployType.String(), exec.Region)
+		if err != nil && handleStateLock(exec, options, out, previousHolders, currentHolder, &output) {
+			return retryPhase(options, phase, action)
+		}
 
-	if exec.Namespace != "" {
-		workspace = fmt.Sprintf("%s-%s", exec.Namespace, workspace)
+		return out, err
 	}
-	resp, output.Err = terraformer.WorkspaceSelect(tfOptions, workspace)
+
+	tfplan := fmt.Sprintf("%s%s%stfplan", exec.StepName, exec.RegionDeployType, exec.Region)
+
+	// terraform plan
+	tfOptions, output.Err = getCommonTfOptions2(exec)
 
 	if output.Err != nil {
-		tfOptions.Logger.WithError(output.Err).Error("Error during terraform init")
+		tfOptions.Logger.WithError(output.Err).Error("Error running terraform plan")
 		return
 	}
 
-	// terraform plan
-	_ = retry.DoWithRetry("terraform plan and apply", tfOptions.MaxRetries, 10*time.Second, tfOptions.Logger, func(attempt int) error {
-
-		retryLogger := tfOptions.Logger.WithField("retryCount", attempt)
+	tfOptions.Logger = tfOptions.Logger.WithField("terraform", "plan")
 
-		tfplan := fmt.Sprintf("%s%s%stfplan", exec.StepName, exec.RegionDeployType, exec.Region)
+	// Set all step parameters as terraform env variables
+	for k, v := range GetTerraformEnvVars(exec) {
+		tfOptions.Logger.Debugf("Adding parameter to TF_VARs: %s", k)
+		tfOptions.EnvVars[fmt.Sprintf("TF_VAR_%s", k)] = v
+	}
 
-		// terraform plan
-		tfOptions, output.Err = getCommonTfOptions2(exec)
+	tfOptions.Vars = GetTerraformCLIVars(exec)
+	tfOptions.VarFiles = GetTerraformVarFiles(exec)
 
-		if output.Err != nil {
-			tfOptions.Logger.WithError(output.Err).Error("Error running terraform plan")
-			return output.Err
-		}
+	tfOptions.Logger.Infof("Applying var files: [%s]", strings.Join(tfOptions.VarFiles, ", "))
 
-		tfOptions.Logger = retryLogger.WithField("terraform", "plan")
+	tfOptions.Targets = exec.Targets
+	tfOptions.Replaces = exec.Replaces
 
-		// Set all step parameters as terraform env variables
-		for k, v := range GetTerraformEnvVars(exec) {
-			tfOptions.Logger.Debugf("Adding parameter to TF_VARs: %s", k)
-			tfOptions.EnvVars[fmt.Sprintf("TF_VAR_%s", k)] = v
-		}
+	if len(exec.Targets) > 0 {
+		tfOptions.Logger.Warnf("Targeting resources, this will be a partial apply: [%s]", strings.Join(exec.Targets, ", "))
+	}
 
-		tfOptions.Vars = GetTerraformCLIVars(exec)
+	if len(exec.Replaces) > 0 {
+		tfOptions.Logger.Warnf("Forcing replacement of resources: [%s]", strings.Join(exec.Replaces, ", "))
+	}
 
-		resp, output.Err = terraformer.Plan(tfOptions, tfplan, destroy)
+	// import the declared existing resources before planning, so they are adopted rather than created
+	if !destroy {
+		output.Err = importResources(exec, tfOptions, lockedPhase)
 
 		if output.Err != nil {
-			tfOptions.Logger.WithError(output.Err).Error("Error running terraform plan")
-			return output.Err
+			tfOptions.Logger.WithError(output.Err).Error("Error running terraform import")
+			return
 		}
+	}
 
-		// validate terraform plan
-		// new options to reset variables
-		baseOptions, err := getCommonTfOptions2(exec)
+	planOptions := tfOptions
 
-		if err != nil {
-			retryLogger.WithError(output.Err).Error("Error retrieving tf options for terraform show")
-		}
+	resp, output.Err = lockedPhase(planOptions, "plan", func(attempt int) (string, error) {
+		return terraformer.Plan(planOptions, tfplan, destroy)
+	})
 
-		baseOptions.Logger = retryLogger.WithField("terraform", "show")
-		resp, output.Err = terraformer.Show(baseOptions, tfplan)
+	recordUIEvents(&output, resp)
 
-		if output.Err != nil {
-			baseOptions.Logger.WithError(output.Err).Errorf("Error during terraform show:\n%s", resp)
-			return output.Err
-		}
+	if output.Err != nil {
+		tfOptions.Logger.WithError(output.Err).Error("Error running terraform plan")
+		return
+	}
 
-		plan := plan{}
-		output.Err = json.Unmarshal([]byte(resp), &plan)
+	// validate terraform plan
+	// new options to reset variables
+	baseOptions, err := getCommonTfOptions2(exec)
 
-		if output.Err != nil {
-			tfOptions.Logger.WithError(output.Err).Error("Error unmarshalling terraform show")
-			return output.Err
-		}
-		// aws_cloudtrail.central_logging_trail, aws_cloudtrail, central_logging_trail: [no-op]
+	if err != nil {
+		tfOptions.Logger.WithError(err).Error("Error retrieving tf options for terraform show")
+	}
 
-		resourceChangesByAction := map[string][]string{}
-		for _, c := range plan.ResourceChanges {
-			key := fmt.Sprintf("%s", c.Change.Actions)
-			if resourceChangesByAction[key] == nil {
-				resourceChangesByAction[key] = []string{}
-			}
+	baseOptions.Logger = baseOptions.Logger.WithField("terraform", "show")
+	resp, output.Err = retryPhase(baseOptions, "show", func(attempt int) (string, error) {
+		return terraformer.Show(baseOptions, tfplan)
+	})
 
-			resourceChangesByAction[key] = append(resourceChangesByAction[key], c.Address)
+	if output.Err != nil {
+		baseOptions.Logger.WithError(output.Err).Errorf("Error during terraform show:\n%s", resp)
+		return
+	}
 
-			tfOptions.Logger.Info(fmt.Sprintf("%s, %s, %s: %s", c.Address, c.Type, c.Name, c.Change.Actions))
-		}
-		applyChanges := true
-		//noChanges := len(resourceChangesByAction["[no-op]"]) == len(plan.ResourceChanges)
+	plan := plan{}
+	output.Err = json.Unmarshal([]byte(resp), &plan)
+
+	if output.Err != nil {
+		tfOptions.Logger.WithError(output.Err).Error("Error unmarshalling terraform show")
+		return
+	}
+
+	summary := summarizePlan(plan)
+	output.Plan = &summary
+	// aws_cloudtrail.central_logging_trail, aws_cloudtrail, central_logging_trail: [no-op]
 
-		// only run apply on when not dry run and changes exist
-		if exec.DryRun {
-			tfOptions.Logger.Info("---------- Skipping apply, this is a dry run ---------- ")
-			applyChanges = false
+	resourceChangesByAction := map[string][]string{}
+	for _, c := range plan.ResourceChanges {
+		key := fmt.Sprintf("%s", c.Change.Actions)
+		if resourceChangesByAction[key] == nil {
+			resourceChangesByAction[key] = []string{}
 		}
 
-		//if noChanges {
-		//	tfOptions.Logger.Info("---------- Skipping apply, no changes detected ---------- ")
-		//	applyChanges = false
-		//}
+		resourceChangesByAction[key] = append(resourceChangesByAction[key], c.Address)
 
-		if applyChanges {
-			// terraform apply
-			baseOptions.Logger = retryLogger.WithField("terraform", "apply")
-			resp, output.Err = terraformer.Apply(baseOptions, tfplan)
+		tfOptions.Logger.Info(fmt.Sprintf("%s, %s, %s: %s", c.Address, c.Type, c.Name, c.Change.Actions))
+	}
+	applyChanges := true
+	//noChanges := len(resourceChangesByAction["[no-op]"]) == len(plan.ResourceChanges)
+
+	// only run apply on when not dry run and changes exist
+	if exec.DryRun {
+		tfOptions.Logger.Info("---------- Skipping apply, this is a dry run ---------- ")
+		applyChanges = false
+	}
 
-			if output.Err != nil {
-				baseOptions.Logger.WithError(output.Err).Error("Error running terraform apply")
-				return output.Err
+	//if noChanges {
+	//	tfOptions.Logger.Info("---------- Skipping apply, no changes detected ---------- ")
+	//	applyChanges = false
+	//}
+
+	if applyChanges {
+		// terraform apply
+		baseOptions.Logger = baseOptions.Logger.WithField("terraform", "apply")
+		resp, output.Err = lockedPhase(baseOptions, "apply", func(attempt int) (string, error) {
+			// a saved plan is stale once an apply has partially run, so retries apply a new plan
+			if attempt > 0 {
+				if out, err := terraformer.Plan(planOptions, tfplan, destroy); err != nil {
+					return out, err
+				}
 			}
-		}
 
-		// parse terraform output
-		baseOptions, output.Err = getCommonTfOptions2(exec)
+			return terraformer.Apply(baseOptions, tfplan)
+		})
+
+		recordUIEvents(&output, resp)
 
 		if output.Err != nil {
-			retryLogger.WithError(output.Err).Error("unable to retrieve credentials for terraform output")
-			return output.Err
+			baseOptions.Logger.WithError(output.Err).Error("Error running terraform apply")
+			return
 		}
+	}
 
-		baseOptions.Logger = retryLogger.WithField("terraform", "output")
+	// parse terraform output
+	baseOptions, output.Err = getCommonTfOptions2(exec)
 
-		output.OutputVariables, output.Err = terraformer.OutputAll(tfOptions)
+	if output.Err != nil {
+		tfOptions.Logger.WithError(output.Err).Error("unable to retrieve credentials for terraform output")
+		return
+	}
 
-		if output.Err != nil {
-			baseOptions.Logger.WithError(output.Err).Error("Error running terraform output")
-		}
+	baseOptions.Logger = baseOptions.Logger.WithField("terraform", "output")
+
+	if err = readTerraformOutputs(baseOptions, &output); err != nil {
+		output.Err = err
+		baseOptions.Logger.WithError(output.Err).Error("Error running terraform output")
+	}
+
+	output.Status = config.Success
 
-		output.Status = config.Success
+	return
+}
+
+// readTerraformOutputs sets the outputs of the execution's state as the step's output variables. Sensitive values are
+// registered as secrets, so they are redacted from all logs.
+func readTerraformOutputs(options *terraform.Options, output *config.StepOutput) (err error) {
+	var outputs map[string]terraform.OutputMeta
 
-		return nil
+	_, err = retryPhase(options, "output", func(attempt int) (string, error) {
+		outputs, err = terraformer.OutputAllWithMeta(options)
+		return "", err
 	})
 
+	output.OutputVariables = map[string]interface{}{}
+	for k, v := range outputs {
+		output.OutputVariables[k] = v.Value
+
+		if v.Sensitive {
+			logging.RegisterSecretValue(v.Value)
+		}
+	}
+	output.SensitiveOutputVariables = terraform.SensitiveOutputKeys(outputs)
+
 	return
 }
 
-// GetBackendConfig parses a backend.tf file
-// TODO, replace this with a cleaner hcl2json2struct merge where backend.tf configurations take priority over defined defaults here
-func GetBackendConfig(exec config.StepExecution, backendParser TFBackendParser) TerraformBackend {
-	declaredBackend := backendParser(exec.Fs, exec.Logger, filepath.Join(exec.Dir, "backend.tf"))
+// terraformWorkspace returns the workspace of the execution, unique per namespace, region deploy type and region
+func terraformWorkspace(exec config.StepExecution) string {
+	workspace := fmt.Sprintf("%s-%s", exec.RegionDeployType.String(), exec.Region)
 
-	exec.Logger.Debugf("Parsed Backend Type: %s", declaredBackend.Type)
-	exec.Logger.Debugf("Parsed Backend Key: %s", declaredBackend.Key)
+	if exec.Namespace != "" {
+		workspace = fmt.Sprintf("%s-%s", exec.Namespace, workspace)
+	}
 
-	backendConfig := map[string]map[string]interface{}{
-		"s3":      {},
-		"azurerm": {},
-		"gcs":     {},
-		"local":   {},
+	return workspace
+}
+
+// initTerraformInDir initializes terraform with the step's backend configuration and selects the execution's workspace
+// when the backend supports workspaces
+func initTerraformInDir(exec config.StepExecution) (tfOptions *terraform.Options, backend TerraformBackend, err error) {
+	tfOptions, err = getCommonTfOptions2(exec)
+
+	if err != nil {
+		tfOptions.Logger.WithError(err).Error("unable to retrieve credentials for terraform init")
+		return
+	}
+
+	backend, err = GetBackendConfig(exec, ParseTFBackend)
+
+	if err != nil {
+		tfOptions.Logger.WithError(err).Error("Error parsing terraform backend")
+		return
+	}
+
+	if backend.Type == LocalBackend {
+		if err = keepLocalState(exec, &backend); err != nil {
+			tfOptions.Logger.WithError(err).Error("Error keeping the local state in the step directory")
+			return
+		}
 	}
 
-	// if user has decided to set a specific backend type, use that and set default values
-	b := backendConfig[declaredBackend.Type.String()]
+	tfOptions.BackendConfig = map[string]interface{}{}
+	backendBlocks := map[string]map[string]interface{}{}
 
-	// if user has decided to overwrite state file convention in backend.tf, support this override
-	if declaredBackend.Key != "" {
-		// grab statefile name (base)
-		b["key"] = interpolateString(exec, declaredBackend.Key)
+	for k, v := range backend.Config {
+		if block, ok := v.(map[string]interface{}); ok {
+			backendBlocks[k] = block
+		} else {
+			tfOptions.BackendConfig[k] = v
+		}
 	}
 
-	if declaredBackend.S3RoleArn != "" {
-		b["role_arn"] = interpolateString(exec, declaredBackend.S3RoleArn)
+	if len(backendBlocks) > 0 {
+		backendConfigFile, err := writeBackendConfigFile(exec.Fs, backendBlocks)
+
+		if err != nil {
+			tfOptions.Logger.WithError(err).Error("Error writing terraform backend configuration")
+			return tfOptions, backend, err
+		}
+
+		defer exec.Fs.Remove(backendConfigFile)
+
+		tfOptions.BackendConfigFiles = []string{backendConfigFile}
+	}
+	tfOptions.Logger = tfOptions.Logger.WithField("terraform", "init")
+	_, err = retryPhase(tfOptions, "init", func(attempt int) (string, error) {
+		return terraformer.Init(tfOptions)
+	})
 
-		exec.Logger.Debugf("Declared S3RoleArn: %s", b["role_arn"])
+	if err != nil {
+		tfOptions.Logger.WithError(err).Error("Error during terraform init")
+		return
 	}
 
-	if declaredBackend.S3Bucket != "" {
-		b["bucket"] = interpolateString(exec, declaredBackend.S3Bucket)
+	if backend.SupportsWorkspaces() {
+		tfOptions.Logger = tfOptions.Logger.WithField("terraform", "workspace")
+
+		workspace := terraformWorkspace(exec)
+
+		_, err = retryPhase(tfOptions, "workspace", func(attempt int) (string, error) {
+			return terraformer.WorkspaceSelect(tfOptions, workspace)
+		})
 
-		exec.Logger.Debugf("Declared S3 bucket: %s", b["bucket"])
+		if err != nil {
+			tfOptions.Logger.WithError(err).Error("Error during terraform workspace select")
+			return
+		}
 	}
 
-	if declaredBackend.GCSBucket != "" {
-		b["bucket"] = interpolateString(exec, declaredBackend.GCSBucket)
+	return
+}
+
+// retryBackoff is the backoff between retries of a terraform phase
+var retryBackoff = retry.DefaultBackoff
+
+// retryPhase runs a single terraform phase, retrying it only when it fails with an error matching the options'
+// retryable errors. Other errors fail the phase immediately.
+func retryPhase(options *terraform.Options, phase string, action func(attempt int) (string, error)) (string, error) {
+	return retry.DoWithRetryableErrors(fmt.Sprintf("terraform %s", phase), options.RetryableTerraformErrors, options.MaxRetries, retryBackoff, options.Logger, action)
+}
+
+// GetBackendConfig parses the step's backend.tf (or backend.tf.json) file and interpolates runiac variables
+// in every declared attribute, which are passed through to terraform init as -backend-config
+func GetBackendConfig(exec config.StepExecution, backendParser TFBackendParser) (TerraformBackend, error) {
+	backendFile := filepath.Join(exec.Dir, "backend.tf")
+
+	if _, err := exec.Fs.Stat(backendFile); os.IsNotExist(err) {
+		if _, jsonErr := exec.Fs.Stat(backendFile + ".json"); jsonErr == nil {
+			backendFile = backendFile + ".json"
+		}
+	}
 
-		exec.Logger.Debugf("Declared GCS bucket: %s", b["bucket"])
+	declaredBackend, err := backendParser(exec.Fs, exec.Logger, backendFile)
+	if err != nil {
+		return declaredBackend, err
 	}
 
-	if declaredBackend.GCSPrefix != "" {
-		b["prefix"] = interpolateString(exec, declaredBackend.GCSPrefix)
+	exec.Logger.Debugf("Parsed Backend Type: %s", declaredBackend.Type)
+
+	b := map[string]interface{}{}
 
-		exec.Logger.Debugf("Declared GCS prefix: %s", b["prefix"])
+	// apply the backend's conventions for anything not declared in backend.tf
+	if convention := TFBackends[declaredBackend.Type]; convention.Defaults != nil {
+		for k, v := range convention.Defaults(exec) {
+			b[k] = v
+		}
 	}
 
-	if declaredBackend.AZUResourceGroupName != "" {
-		b["resource_group_name"] = interpolateString(exec, declaredBackend.AZUResourceGroupName)
+	for k, v := range declaredBackend.Attributes {
+		b[k] = v
 	}
 
-	if declaredBackend.AZUStorageAccountName != "" {
-		b["storage_account_name"] = interpolateString(exec, declaredBackend.AZUStorageAccountName)
+	for k, v := range b {
+		b[k] = interpolateBackendValue(exec, v)
 	}
 
-	if declaredBackend.AZUStorageAccountName != "" {
-		b["storage_account_name"] = interpolateString(exec, declaredBackend.AZUStorageAccountName)
+	// credentials are registered before the configuration is logged or passed to terraform init
+	for _, k := range TFBackends[declaredBackend.Type].Sensitive {
+		logging.RegisterSecretValue(b[k])
 	}
 
-	if declaredBackend.Path != "" {
-		b["path"] = interpolateString(exec, declaredBackend.Path)
+	for k, v := range b {
+		exec.Logger.Debugf("Backend %s: %v", k, v)
 	}
 
 	declaredBackend.Config = b
 
-	return declaredBackend
+	return declaredBackend, nil
+}
+
+// interpolateBackendValue applies interpolateString to a backend attribute, including the attributes of nested blocks
+func interpolateBackendValue(exec config.StepExecution, v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return interpolateString(exec, val)
+	case map[string]interface{}:
+		interpolated := map[string]interface{}{}
+		for k, nested := range val {
+			interpolated[k] = interpolateBackendValue(exec, nested)
+		}
+		return interpolated
+	}
+
+	return v
 }
 
 func interpolateString(exec config.StepExecution, s string) string {
@@ -428,6 +715,16 @@ func interpolateString(exec config.StepExecution, s string) string {
 			"${var.runiac_environment}", exec.Environment)
 	}
 
+	if strings.Contains(s, "${var.runiac_account_id}") {
+		s = strings.ReplaceAll(s,
+			"${var.runiac_account_id}", exec.AccountID)
+	}
+
+	if strings.Contains(s, "${var.runiac_namespace}") {
+		s = strings.ReplaceAll(s,
+			"${var.runiac_namespace}", exec.Namespace)
+	}
+
 	// Replace all ${var.core_account_ids_map instances.
 	// There could be multiple ${var.core_account_ids_map references in the string,
 	if strings.Contains(s, "${var.core_account_ids_map") {
@@ -461,15 +758,40 @@ func interpolateString(exec config.StepExecution, s string) string {
 	return s
 }
 
+// ResolveTerraformFlavor returns the flavor to execute the step with, the step's runiac.yml takes precedence over
+// the global setting
+func ResolveTerraformFlavor(exec config.StepExecution) (terraform.Flavor, error) {
+	if exec.StepConfig.TerraformFlavor != "" {
+		return terraform.ParseFlavor(exec.StepConfig.TerraformFlavor)
+	}
+
+	return terraform.ParseFlavor(exec.TerraformFlavor)
+}
+
 func getCommonTfOptions2(exec config.StepExecution) (tfOptions *terraform.Options, err error) {
 	tfOptions = &terraform.Options{
-		TerraformDir:             exec.Dir,
-		EnvVars:                  map[string]string{},
-		Logger:                   exec.Logger,
-		NoColor:                  true,
-		RetryableTerraformErrors: map[string]string{".*": "General Terraform error occurred."},
-		MaxRetries:               exec.MaxRetries,
-		TimeBetweenRetries:       5 * time.Second,
+		TerraformBinary:         exec.RunnerBinary,
+		Flavor:                  terraform.Flavor(exec.TerraformFlavor),
+		PluginCacheDir:          exec.PluginCacheDir,
+		PluginCacheMayBreakLock: exec.PluginCacheMayBreakLock,
+		TerraformDir:            exec.Dir,
+		EnvVars:                 map[string]string{},
+		Logger:                  exec.Logger,
+		NoColor:                 true,
+		MaxRetries:              exec.MaxRetries,
+		TimeBetweenRetries:      retryBackoff.Initial,
+		JSONUI:                  exec.TerraformJSONUI,
+	}
+
+	tfOptions.RetryableTerraformErrors, err = retry.MergeRetryableErrors(terraform.DefaultRetryableTerraformErrors, exec.RetryableErrors)
+	if err != nil {
+		return
+	}
+
+	// terragrunt wraps terraform, so a selected terraform binary is passed to terragrunt rather than executed
+	if tfOptions.Flavor == terraform.TerragruntFlavor && exec.RunnerBinary != "" {
+		tfOptions.TerraformBinary = ""
+		tfOptions.EnvVars["TERRAGRUNT_TFPATH"] = exec.RunnerBinary
 	}
 
 	return
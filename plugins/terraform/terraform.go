@@ -4,174 +4,18 @@ package plugins_terraform
 
 import (
 	"encoding/json"
-	"fmt"
-	"github.com/go-errors/errors"
-	"github.com/optum/runiac/pkg/config"
-	"github.com/sirupsen/logrus"
-	"github.com/spf13/afero"
-	"path/filepath"
-	"regexp"
-	"strings"
 )
 
-// TFBackendType represents a Terraform backend type
-type TFBackendType int
-
-const (
-	// S3Backend backend
-	S3Backend TFBackendType = iota
-	// Azure Storage Account backend
-	AzureStorageAccount
-	// Google Cloud Storage backend
-	GCSBackend
-	// LocalBackend backend
-	LocalBackend
-	// UnknownBackend represents an unknown backend
-	UnknownBackend
-)
-
-func (b TFBackendType) String() string {
-	return [...]string{"s3", "azurerm", "gcs", "local", "unknown"}[b]
-}
-
-// StringToBackendType converts a string to a ProviderType
-func StringToBackendType(s string) (TFBackendType, error) {
-	backends := map[string]TFBackendType{
-		"s3":      S3Backend,
-		"azurerm": AzureStorageAccount,
-		"gcs":     GCSBackend,
-		"local":   LocalBackend,
-	}
-
-	val, exists := backends[s]
-	if !exists {
-		return UnknownBackend, errors.New("Invalid backend string")
-	}
-	return val, nil
-}
-
-// TerraformBackend is a structure that represents a terraform backend file
-type TerraformBackend struct {
-	Type                  TFBackendType
-	Key                   string
-	S3RoleArn             string
-	S3Bucket              string
-	AZUResourceGroupName  string
-	AZUStorageAccountName string
-	GCSBucket             string
-	GCSPrefix             string
-	Path                  string
-	Config                map[string]interface{}
-}
-
-// TFBackendParser is a function type that handles parsing a backend.tf file
-type TFBackendParser func(fs afero.Fs, log *logrus.Entry, file string) (backend TerraformBackend)
-
-func getStateFile(tfStateName string, namespace string, ring string, environment string, region string, regionType config.RegionDeployType) string {
-	var namespacedStateFile = tfStateName
-
-	if namespace != "" {
-		namespacedStateFile = fmt.Sprintf("%s-%s", namespace, namespacedStateFile)
-	}
-
-	if region != "us-east-1" || regionType == config.RegionalRegionDeployType {
-		regionNamespace := fmt.Sprintf("%s-%s", regionType.String(), region)
-		namespacedStateFile = filepath.Join(namespacedStateFile, regionNamespace)
-	}
-
-	return namespacedStateFile
-}
-
-// ParseTFBackend parses a backend.tf file
-func ParseTFBackend(fs afero.Fs, log *logrus.Entry, file string) (backend TerraformBackend) {
-	backendString := "backend \""
-	// read the whole file at once
-	b, err := afero.ReadFile(fs, file)
-	if err != nil {
-		log.WithError(err).Error(err)
-		backend.Type = LocalBackend
-		return
-	}
-
-	s := string(b)
-
-	// backend Type
-	backendType, backendErr := StringToBackendType(strings.Split(strings.Split(s, backendString)[1], "\"")[0])
-	if backendErr != nil {
-		log.WithError(backendErr).Fatal("Invalid backend type")
-	}
-	backend.Type = backendType
-
-	// Key
-	r, _ := regexp.Compile(`key\s*=\s*"(.+)"`)
-	substring := r.FindStringSubmatch(s)
-
-	if len(substring) > 0 {
-		backend.Key = substring[1]
-	}
-
-	// RoleArn
-	rRegex, _ := regexp.Compile(`role_arn\s*=\s*"(.+)"`)
-	roleMatch := rRegex.FindStringSubmatch(s)
-
-	if len(roleMatch) > 0 {
-		backend.S3RoleArn = roleMatch[1]
-	}
-
-	// Bucket
-	bRegex, _ := regexp.Compile(`bucket\s*=\s*"(.+)"`)
-	bucketMatch := bRegex.FindStringSubmatch(s)
-
-	if len(bucketMatch) > 0 {
-		if backendType == S3Backend {
-			backend.S3Bucket = bucketMatch[1]
-		} else if backendType == GCSBackend {
-			backend.GCSBucket = bucketMatch[1]
-		}
-	}
-
-	// Prefix
-	pRegex, _ := regexp.Compile(`prefix\s*=\s*"(.+)"`)
-	prefixMatch := pRegex.FindStringSubmatch(s)
-
-	if backendType == GCSBackend && len(prefixMatch) > 0 {
-		backend.GCSPrefix = prefixMatch[1]
-	}
-
-	// Resource group (Azure)
-	rgRegex, _ := regexp.Compile(`resource_group_name\s*=\s*"(.+)"`)
-	resourceGroupMatch := rgRegex.FindStringSubmatch(s)
-
-	if len(resourceGroupMatch) > 0 {
-		backend.AZUResourceGroupName = resourceGroupMatch[1]
-	}
-
-	// Storage account (Azure)
-	stRegex, _ := regexp.Compile(`storage_account_name\s*=\s*"(.+)"`)
-	storageAccountMatch := stRegex.FindStringSubmatch(s)
-
-	if len(storageAccountMatch) > 0 {
-		backend.AZUStorageAccountName = storageAccountMatch[1]
-	}
-
-	// Storage account (Azure)
-	pathRegex, _ := regexp.Compile(`path\s*=\s*"(.+)"`)
-	pathMatch := pathRegex.FindStringSubmatch(s)
-
-	if len(pathMatch) > 0 {
-		backend.Path = pathMatch[1]
-	}
-
-	return
-}
-
 // Plan is the top-level representation of the json format of a plan. It includes
 // the complete config and current state.
 type plan struct {
-	FormatVersion    string `json:"format_version,omitempty"`
-	TerraformVersion string `json:"terraform_version,omitempty"`
-	//Variables        variables   `json:"variables,omitempty"`
-	//PlannedValues    stateValues `json:"planned_values,omitempty"`
+	FormatVersion    string      `json:"format_version,omitempty"`
+	TerraformVersion string      `json:"terraform_version,omitempty"`
+	Variables        variables   `json:"variables,omitempty"`
+	PlannedValues    stateValues `json:"planned_values,omitempty"`
+	// ResourceDrift are the changes made outside of terraform since the
+	// prior state was last refreshed. Only reported by terraform 0.15.4+.
+	ResourceDrift []resourceChange `json:"resource_drift,omitempty"`
 	// ResourceChanges are sorted in a user-friendly order that is undefined at
 	// this time, but consistent.
 	ResourceChanges []resourceChange  `json:"resource_changes,omitempty"`
@@ -196,8 +40,15 @@ type resourceChange struct {
 
 	Type string `json:"type,omitempty"`
 	Name string `json:"name,omitempty"`
-	//Index        addrs.InstanceKey `json:"index,omitempty"`
-	ProviderName string `json:"provider_name,omitempty"`
+
+	// Index is the instance key of a resource using count or for_each, either
+	// a number or a string. Omitted for single instances.
+	Index        json.RawMessage `json:"index,omitempty"`
+	ProviderName string          `json:"provider_name,omitempty"`
+
+	// PreviousAddress is the address of the instance before it was moved.
+	// Omitted when it was not moved. Only reported by terraform 1.1+.
+	PreviousAddress string `json:"previous_address,omitempty"`
 
 	// "deposed", if set, indicates that this action applies to a "deposed"
 	// object of the given instance rather than to its "current" object. Omitted
@@ -206,6 +57,11 @@ type resourceChange struct {
 
 	// Change describes the change that will be made to this object
 	Change change `json:"change,omitempty"`
+
+	// ActionReason is an optional hint about why the actions were planned,
+	// e.g. "replace_because_tainted" or "delete_because_no_resource_config".
+	// Only reported by terraform 0.15.4+.
+	ActionReason string `json:"action_reason,omitempty"`
 }
 
 // Change is the representation of a proposed change for an object.
@@ -233,4 +89,54 @@ type change struct {
 	Before       json.RawMessage `json:"before,omitempty"`
 	After        json.RawMessage `json:"after,omitempty"`
 	AfterUnknown json.RawMessage `json:"after_unknown,omitempty"`
+
+	// BeforeSensitive and AfterSensitive mirror the structure of Before and
+	// After with true for each sensitive value, or are true when the whole
+	// value is sensitive. Only reported by terraform 0.15+.
+	BeforeSensitive json.RawMessage `json:"before_sensitive,omitempty"`
+	AfterSensitive  json.RawMessage `json:"after_sensitive,omitempty"`
+
+	// ReplacePaths are the paths of the attributes forcing a replacement,
+	// each a list of attribute names and collection keys.
+	ReplacePaths [][]interface{} `json:"replace_paths,omitempty"`
+}
+
+// variables are the values of the root module's input variables
+type variables map[string]*variable
+
+type variable struct {
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// stateValues is the representation of the planned values of the resources
+// and outputs.
+type stateValues struct {
+	Outputs    map[string]output `json:"outputs,omitempty"`
+	RootModule module            `json:"root_module,omitempty"`
+}
+
+type output struct {
+	Sensitive bool            `json:"sensitive"`
+	Value     json.RawMessage `json:"value,omitempty"`
+}
+
+// module is the representation of a module in state, containing its
+// resources and child modules.
+type module struct {
+	Resources    []resource `json:"resources,omitempty"`
+	Address      string     `json:"address,omitempty"`
+	ChildModules []module   `json:"child_modules,omitempty"`
+}
+
+// resource is the representation of a resource instance in state.
+type resource struct {
+	Address         string          `json:"address,omitempty"`
+	Mode            string          `json:"mode,omitempty"`
+	Type            string          `json:"type,omitempty"`
+	Name            string          `json:"name,omitempty"`
+	Index           json.RawMessage `json:"index,omitempty"`
+	ProviderName    string          `json:"provider_name,omitempty"`
+	SchemaVersion   uint64          `json:"schema_version"`
+	AttributeValues json.RawMessage `json:"values,omitempty"`
+	SensitiveValues json.RawMessage `json:"sensitive_values,omitempty"`
 }
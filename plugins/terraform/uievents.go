@@ -0,0 +1,62 @@
+package plugins_terraform
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+)
+
+// minJSONUIVersion is the first terraform version streaming its machine readable UI from plan and apply
+var minJSONUIVersion = version.Must(version.NewVersion("0.15.3"))
+
+// SupportsJSONUI returns whether the terraform version streams its machine readable UI from plan and apply. Unknown
+// versions, e.g. terraform on PATH, are assumed to support it.
+func SupportsJSONUI(v string) bool {
+	if v == "" {
+		return true
+	}
+
+	parsed, err := version.NewVersion(v)
+	if err != nil {
+		return true
+	}
+
+	return !parsed.LessThan(minJSONUIVersion)
+}
+
+// recordUIEvents records the diagnostics and resource results of the machine readable UI events within a plan or
+// apply's output on the step's output. Error diagnostics are attached to the step's error with their location.
+func recordUIEvents(output *config.StepOutput, out string) {
+	events := terraform.ParseUIEvents(out)
+
+	for _, event := range events {
+		if d := event.Diagnostic; d != nil {
+			diagnostic := config.Diagnostic{
+				Severity: d.Severity,
+				Summary:  d.Summary,
+				Detail:   d.Detail,
+				Address:  d.Address,
+			}
+
+			if d.Range != nil {
+				diagnostic.Filename = d.Range.Filename
+				diagnostic.Line = d.Range.Start.Line
+			}
+
+			output.Diagnostics = append(output.Diagnostics, diagnostic)
+		}
+
+		if event.Hook != nil && (event.Type == terraform.UIEventApplyComplete || event.Type == terraform.UIEventApplyErrored) {
+			output.ResourceResults = append(output.ResourceResults, config.ResourceResult{
+				Address:        event.Hook.Resource.Addr,
+				Action:         event.Hook.Action,
+				Errored:        event.Type == terraform.UIEventApplyErrored,
+				ElapsedSeconds: event.Hook.ElapsedSeconds,
+			})
+		}
+	}
+
+	if diagnostics := terraform.ErrorDiagnostics(events); output.Err != nil && len(diagnostics) > 0 {
+		output.Err = &terraform.DiagnosticsError{Err: output.Err, Diagnostics: diagnostics}
+	}
+}
@@ -0,0 +1,61 @@
+package plugins_terraform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportsJSONUI(t *testing.T) {
+	tests := map[string]bool{
+		"":        true,
+		"0.14.4":  false,
+		"0.15.2":  false,
+		"0.15.3":  true,
+		"1.3.9":   true,
+		"unknown": true,
+	}
+
+	for v, expected := range tests {
+		require.Equal(t, expected, SupportsJSONUI(v), v)
+	}
+}
+
+func TestRecordUIEvents_ShouldAttachDiagnosticsToError(t *testing.T) {
+	out := `{"@level":"info","@message":"aws_vpc.main: Creation complete after 2s","type":"apply_complete","hook":{"resource":{"addr":"aws_vpc.main"},"action":"create","elapsed_seconds":2}}
+{"@level":"error","@message":"aws_subnet.a: Creation errored after 1s","type":"apply_errored","hook":{"resource":{"addr":"aws_subnet.a"},"action":"create","elapsed_seconds":1}}
+{"@level":"warn","@message":"Warning: Deprecated attribute","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Deprecated attribute","address":"aws_vpc.main"}}
+{"@level":"error","@message":"Error: creating subnet","type":"diagnostic","diagnostic":{"severity":"error","summary":"creating subnet","detail":"InvalidSubnet.Range","address":"aws_subnet.a","range":{"filename":"subnets.tf","start":{"line":4}}}}`
+
+	cause := errors.New("exit status 1")
+	output := config.StepOutput{Err: cause}
+
+	recordUIEvents(&output, out)
+
+	require.Equal(t, []config.ResourceResult{
+		{Address: "aws_vpc.main", Action: "create", ElapsedSeconds: 2},
+		{Address: "aws_subnet.a", Action: "create", Errored: true, ElapsedSeconds: 1},
+	}, output.ResourceResults)
+
+	require.Len(t, output.Diagnostics, 2)
+	require.Equal(t, config.Diagnostic{Severity: "error", Summary: "creating subnet", Detail: "InvalidSubnet.Range", Address: "aws_subnet.a", Filename: "subnets.tf", Line: 4}, output.Diagnostics[1])
+
+	var diagnosticsErr *terraform.DiagnosticsError
+	require.True(t, errors.As(output.Err, &diagnosticsErr))
+	require.True(t, errors.Is(output.Err, cause))
+	require.Contains(t, output.Err.Error(), "Error: creating subnet (subnets.tf:4): InvalidSubnet.Range")
+	require.NotContains(t, output.Err.Error(), "Deprecated attribute")
+}
+
+func TestRecordUIEvents_ShouldIgnoreTextOutput(t *testing.T) {
+	output := config.StepOutput{}
+
+	recordUIEvents(&output, "aws_vpc.main: Creating...\nApply complete! Resources: 1 added, 0 changed, 0 destroyed.")
+
+	require.Empty(t, output.Diagnostics)
+	require.Empty(t, output.ResourceResults)
+	require.NoError(t, output.Err)
+}
@@ -0,0 +1,181 @@
+package plugins_terraform
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var terraformBlockVersionSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "required_version"},
+	},
+}
+
+// ParseRequiredVersions returns the required_version constraints declared in the terraform blocks of the
+// *.tf and *.tf.json files within dir
+func ParseRequiredVersions(fs afero.Fs, dir string) ([]string, error) {
+	files := []string{}
+
+	for _, pattern := range []string{"*.tf", "*.tf.json"} {
+		matches, err := afero.Glob(fs, filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	constraints := []string{}
+
+	for _, file := range files {
+		b, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return nil, err
+		}
+
+		f, diags := parseTerraformFile(b, file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+		}
+
+		content, _, diags := f.Body.PartialContent(backendFileSchema)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+		}
+
+		for _, block := range content.Blocks {
+			tfContent, _, diags := block.Body.PartialContent(terraformBlockVersionSchema)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("unable to parse %s: %s", file, diags.Error())
+			}
+
+			if attr, ok := tfContent.Attributes["required_version"]; ok {
+				constraint, err := staticString(attr.Expr)
+				if err != nil {
+					return nil, fmt.Errorf("unable to parse required_version in %s: %w", file, err)
+				}
+				constraints = append(constraints, constraint)
+			}
+		}
+	}
+
+	return constraints, nil
+}
+
+// parseTerraformFile parses a terraform configuration file in the native or json syntax, by its extension
+func parseTerraformFile(src []byte, file string) (*hcl.File, hcl.Diagnostics) {
+	if strings.HasSuffix(file, ".json") {
+		return hcljson.Parse(src, file)
+	}
+
+	return hclsyntax.ParseConfig(src, file, hcl.Pos{Line: 1, Column: 1})
+}
+
+// staticString evaluates an expression that must be a literal string, e.g. a version constraint
+func staticString(expr hcl.Expression) (string, error) {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	if !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+		return "", fmt.Errorf("expected a string, got %s", val.Type().FriendlyName())
+	}
+
+	return val.AsString(), nil
+}
+
+// InstalledTerraformVersions returns the terraform versions installed in dir, laid out as {dir}/{version}/{binary},
+// sorted from newest to oldest
+func InstalledTerraformVersions(fs afero.Fs, dir string, binary string) ([]*version.Version, error) {
+	entries, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read terraform versions directory %s: %w", dir, err)
+	}
+
+	versions := []*version.Version{}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		v, err := version.NewVersion(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		if exists, _ := afero.Exists(fs, filepath.Join(dir, entry.Name(), binary)); exists {
+			versions = append(versions, v)
+		}
+	}
+
+	sort.Sort(sort.Reverse(version.Collection(versions)))
+
+	return versions, nil
+}
+
+// ResolveTerraformBinary selects the newest installed terraform version matching the step's version constraint.
+// The constraint is read from the step's runiac.yml terraform_version, falling back to the required_version
+// declared in the step's terraform files. When no versions directory is configured, terraform on PATH is used.
+// The tofu flavor selects an installed tofu binary, the terraform and terragrunt flavors select a terraform binary.
+func ResolveTerraformBinary(exec config.StepExecution) (binary string, v string, err error) {
+	if exec.TerraformVersionsDir == "" {
+		return "", "", nil
+	}
+
+	binaryName := terraform.TerraformFlavor.Binary()
+	if terraform.Flavor(exec.TerraformFlavor) == terraform.OpenTofuFlavor {
+		binaryName = terraform.OpenTofuFlavor.Binary()
+	}
+
+	constraints := []string{}
+
+	if exec.StepConfig.TerraformVersion != "" {
+		constraints = append(constraints, exec.StepConfig.TerraformVersion)
+	} else {
+		constraints, err = ParseRequiredVersions(exec.Fs, exec.Dir)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	// without any constraint, the newest installed version is used
+	constraint := version.Constraints{}
+
+	if len(constraints) > 0 {
+		constraint, err = version.NewConstraint(strings.Join(constraints, ","))
+		if err != nil {
+			return "", "", fmt.Errorf("invalid terraform version constraint %q: %w", strings.Join(constraints, ","), err)
+		}
+	}
+
+	installed, err := InstalledTerraformVersions(exec.Fs, exec.TerraformVersionsDir, binaryName)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, candidate := range installed {
+		if constraint.Check(candidate) {
+			return filepath.Join(exec.TerraformVersionsDir, candidate.Original(), binaryName), candidate.String(), nil
+		}
+	}
+
+	installedStrings := make([]string, 0, len(installed))
+	for _, candidate := range installed {
+		installedStrings = append(installedStrings, candidate.Original())
+	}
+
+	return "", "", fmt.Errorf("no %s version installed in %s satisfies %q, installed versions: [%s]",
+		binaryName, exec.TerraformVersionsDir, constraint.String(), strings.Join(installedStrings, ", "))
+}
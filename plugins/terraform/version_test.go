@@ -0,0 +1,174 @@
+package plugins_terraform
+
+import (
+	"testing"
+
+	"github.com/optum/runiac/pkg/config"
+	"github.com/optum/runiac/plugins/terraform/pkg/terraform"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func stubTerraformVersionsFs(versions ...string) afero.Fs {
+	fs := afero.NewMemMapFs()
+
+	for _, v := range versions {
+		_ = afero.WriteFile(fs, "/opt/terraform/"+v+"/terraform", []byte(""), 0755)
+	}
+
+	// directories that are not versions or do not contain a binary are ignored
+	_ = fs.MkdirAll("/opt/terraform/1.4.0", 0755)
+	_ = fs.MkdirAll("/opt/terraform/latest", 0755)
+
+	return fs
+}
+
+func TestResolveTerraformBinary_ShouldSelectNewestMatchingRequiredVersion(t *testing.T) {
+	fs := stubTerraformVersionsFs("0.13.5", "1.2.9", "1.3.9", "1.3.2")
+
+	_ = afero.WriteFile(fs, "/step/versions.tf", []byte(`
+terraform {
+  required_version = "~> 1.3.0"
+}
+`), 0644)
+	_ = afero.WriteFile(fs, "/step/main.tf", []byte(`
+terraform {
+  required_version = "< 1.3.9"
+}
+`), 0644)
+
+	binary, v, err := ResolveTerraformBinary(config.StepExecution{
+		Fs:                   fs,
+		Dir:                  "/step",
+		TerraformVersionsDir: "/opt/terraform",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "/opt/terraform/1.3.2/terraform", binary)
+	require.Equal(t, "1.3.2", v)
+}
+
+func TestResolveTerraformBinary_ShouldPreferStepConfig(t *testing.T) {
+	fs := stubTerraformVersionsFs("0.13.5", "1.3.9")
+
+	_ = afero.WriteFile(fs, "/step/versions.tf", []byte(`
+terraform {
+  required_version = ">= 1.0"
+}
+`), 0644)
+
+	binary, _, err := ResolveTerraformBinary(config.StepExecution{
+		Fs:                   fs,
+		Dir:                  "/step",
+		TerraformVersionsDir: "/opt/terraform",
+		StepConfig:           config.StepConfig{TerraformVersion: "0.13.5"},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "/opt/terraform/0.13.5/terraform", binary)
+}
+
+func TestResolveTerraformBinary_ShouldUseNewestWithoutConstraint(t *testing.T) {
+	fs := stubTerraformVersionsFs("0.13.5", "1.3.9")
+
+	binary, _, err := ResolveTerraformBinary(config.StepExecution{
+		Fs:                   fs,
+		Dir:                  "/step",
+		TerraformVersionsDir: "/opt/terraform",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "/opt/terraform/1.3.9/terraform", binary)
+}
+
+func TestResolveTerraformBinary_ShouldErrorWhenNoVersionMatches(t *testing.T) {
+	fs := stubTerraformVersionsFs("0.13.5", "1.3.9")
+
+	_, _, err := ResolveTerraformBinary(config.StepExecution{
+		Fs:                   fs,
+		Dir:                  "/step",
+		TerraformVersionsDir: "/opt/terraform",
+		StepConfig:           config.StepConfig{TerraformVersion: ">= 1.4"},
+	})
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1.3.9, 0.13.5")
+}
+
+func TestResolveTerraformBinary_ShouldUsePathWithoutVersionsDir(t *testing.T) {
+	binary, v, err := ResolveTerraformBinary(config.StepExecution{
+		Fs:         afero.NewMemMapFs(),
+		Dir:        "/step",
+		StepConfig: config.StepConfig{TerraformVersion: "1.3.9"},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "", binary)
+	require.Equal(t, "", v)
+}
+
+func TestResolveTerraformBinary_ShouldSelectTofuBinaryForTofuFlavor(t *testing.T) {
+	fs := stubTerraformVersionsFs("1.3.9")
+	_ = afero.WriteFile(fs, "/opt/terraform/1.6.0/tofu", []byte(""), 0755)
+
+	binary, v, err := ResolveTerraformBinary(config.StepExecution{
+		Fs:                   fs,
+		Dir:                  "/step",
+		TerraformVersionsDir: "/opt/terraform",
+		TerraformFlavor:      "tofu",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "/opt/terraform/1.6.0/tofu", binary)
+	require.Equal(t, "1.6.0", v)
+}
+
+func TestResolveTerraformFlavor_ShouldPreferStepConfig(t *testing.T) {
+	flavor, err := ResolveTerraformFlavor(config.StepExecution{TerraformFlavor: "terraform"})
+	require.NoError(t, err)
+	require.Equal(t, terraform.TerraformFlavor, flavor)
+
+	flavor, err = ResolveTerraformFlavor(config.StepExecution{
+		TerraformFlavor: "terraform",
+		StepConfig:      config.StepConfig{TerraformFlavor: "terragrunt"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, terraform.TerragruntFlavor, flavor)
+
+	_, err = ResolveTerraformFlavor(config.StepExecution{StepConfig: config.StepConfig{TerraformFlavor: "pulumi"}})
+	require.Error(t, err)
+}
+
+func TestGetCommonTfOptions_ShouldPassSelectedTerraformToTerragrunt(t *testing.T) {
+	options, err := getCommonTfOptions2(config.StepExecution{
+		TerraformFlavor: "terragrunt",
+		RunnerBinary:    "/opt/terraform/1.3.9/terraform",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "", options.TerraformBinary)
+	require.Equal(t, "/opt/terraform/1.3.9/terraform", options.EnvVars["TERRAGRUNT_TFPATH"])
+}
+
+func TestParseRequiredVersions_ShouldReadJSONAndRejectNonStrings(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	_ = afero.WriteFile(fs, "/step/versions.tf.json", []byte(`{"terraform": {"required_version": "~> 1.3.0"}}`), 0644)
+	_ = afero.WriteFile(fs, "/step/main.tf", []byte(`
+terraform {
+  required_version = "< 1.3.9"
+}
+`), 0644)
+
+	constraints, err := ParseRequiredVersions(fs, "/step")
+
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"~> 1.3.0", "< 1.3.9"}, constraints)
+
+	for _, value := range []string{"1", "null", `["1.3.0"]`} {
+		_ = afero.WriteFile(fs, "/invalid/versions.tf", []byte("terraform {\n  required_version = "+value+"\n}\n"), 0644)
+
+		_, err = ParseRequiredVersions(fs, "/invalid")
+		require.Error(t, err, value)
+	}
+}